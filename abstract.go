@@ -13,6 +13,8 @@ type absNode struct {
 	Value  *string
 	Rule   *rule
 	Flags  Flags
+	// The file the value was read from if the value was provided as '@/path/to/file'
+	Origin string
 	/*IsCmd      bool
 	CmdHandled bool*/
 	ValueFor *absNode
@@ -173,3 +175,23 @@ func convToKind(values []string, flags Flags, count int) (interface{}, int, erro
 func (a *abstract) Source() string {
 	return cliSource
 }
+
+func (a *abstract) Origin(ctx context.Context, key string) (string, string) {
+	rule := a.rules.GetRule(key)
+	if rule == nil {
+		return "", ""
+	}
+
+	var values, positions []string
+	for _, node := range a.FindRules(rule) {
+		if node.Value != nil {
+			values = append(values, *node.Value)
+		}
+		if node.Origin != "" {
+			positions = append(positions, node.Origin)
+			continue
+		}
+		positions = append(positions, fmt.Sprintf("argv[%d]", node.Pos))
+	}
+	return strings.Join(values, ","), strings.Join(positions, ", ")
+}
@@ -11,20 +11,44 @@ type Variant interface {
 }
 
 // Any struct the implements this interface can be used by `Add()` to
-// store the value for a option or argument.
+// store the value for a option or argument. A SetValue which implements `fmt.Stringer` has
+// its value at the time it is added displayed as the default value in help if no 'Default'
+// is provided.
 // TODO: Reference an example
 type SetValue interface {
 	Set(string) error
 }
 
+// A SetValue can optionally implement this interface to provide the usage placeholder
+// displayed in help; IE: returning 'point' displays '--origin <point>'
+type TypedValue interface {
+	Type() string
+}
+
+// A SetValue can optionally implement this interface to indicate the option expects no value.
+// When the option is provided on the command line Set("true") is called.
+type BoolValue interface {
+	IsBoolFlag() bool
+}
+
+// A SetValue can optionally implement this interface to be treated as a single value. Set() is
+// called once with the value provided instead of once for each item of a comma separated list.
+type ScalarValue interface {
+	IsScalar() bool
+}
+
 type Option struct {
-	Name      string
-	Help      string
-	Env       string
-	Default   string
-	Aliases   []string
-	Flags     Flags
-	DependsOn string // TODO: Implement dependency
+	Name       string
+	Help       string
+	Env        string
+	Default    string
+	Aliases    []string
+	Flags      Flags
+	Choices    []Choice
+	Validators []Validator
+	Cast       CastFunc
+	Action     ActionFunc
+	DependsOn  string // TODO: Implement dependency
 
 	Store interface{}
 
@@ -63,11 +87,15 @@ func (f *Option) toRule() (*rule, error) {
 	}
 
 	r := &rule{
-		Name:    f.Name,
-		HelpMsg: f.Help,
-		Aliases: append(f.Aliases, f.Name),
-		EnvVar:  f.Env,
-		Flags:   f.Flags,
+		Name:       f.Name,
+		HelpMsg:    f.Help,
+		Aliases:    append(f.Aliases, f.Name),
+		EnvVar:     f.Env,
+		Flags:      f.Flags,
+		Choices:    f.Choices,
+		Validators: f.Validators,
+		Cast:       f.Cast,
+		Action:     f.Action,
 	}
 
 	if f.Store != nil {
@@ -86,24 +114,34 @@ func (f *Option) toRule() (*rule, error) {
 	if f.Count != nil {
 		r.SetFlag(CanRepeat, true)
 		r.StoreFuncs = append(r.StoreFuncs, toCount(f.Count))
+		r.Markers = append(r.Markers, f.Count)
 	}
 	if f.IsSet != nil {
 		r.StoreFuncs = append(r.StoreFuncs, toSet(f.IsSet))
+		r.Markers = append(r.Markers, f.IsSet)
+	}
+	// Actions without a 'Store' such as 'append_const' are expected to be repeated
+	if f.Action != nil && f.Store == nil {
+		r.SetFlag(CanRepeat, true)
 	}
 
 	// TODO: Should check for a StoreFunc() instead
-	if f.IsSet == nil && f.Store == nil && f.Count == nil {
-		return nil, fmt.Errorf("refusing to add option '%s'; provide an 'IsSet', 'Store' or 'Count' field", f.Name)
+	if f.IsSet == nil && f.Store == nil && f.Count == nil && f.Action == nil {
+		return nil, fmt.Errorf("refusing to add option '%s'; provide an 'IsSet', 'Store', 'Count' or 'Action' field", f.Name)
 	}
 	return r, nil
 }
 
 type Argument struct {
-	Name    string
-	Help    string
-	Env     string
-	Default string
-	Flags   Flags
+	Name       string
+	Help       string
+	Env        string
+	Default    string
+	Flags      Flags
+	Choices    []Choice
+	Validators []Validator
+	Cast       CastFunc
+	Action     ActionFunc
 
 	Store interface{}
 	Count *int
@@ -120,10 +158,14 @@ func (a *Argument) toRule() (*rule, error) {
 	}
 
 	r := &rule{
-		Name:    a.Name,
-		HelpMsg: a.Help,
-		EnvVar:  a.Env,
-		Flags:   a.Flags,
+		Name:       a.Name,
+		HelpMsg:    a.Help,
+		EnvVar:     a.Env,
+		Flags:      a.Flags,
+		Choices:    a.Choices,
+		Validators: a.Validators,
+		Cast:       a.Cast,
+		Action:     a.Action,
 	}
 
 	if a.Store != nil {
@@ -141,24 +183,27 @@ func (a *Argument) toRule() (*rule, error) {
 		// TODO: Test can repeat for args
 		r.SetFlag(CanRepeat, true)
 		r.StoreFuncs = append(r.StoreFuncs, toCount(a.Count))
+		r.Markers = append(r.Markers, a.Count)
 	}
 	if a.IsSet != nil {
 		r.StoreFuncs = append(r.StoreFuncs, toSet(a.IsSet))
+		r.Markers = append(r.Markers, a.IsSet)
 	}
 
-	if a.IsSet == nil && a.Store == nil && a.Count == nil {
-		return nil, fmt.Errorf("refusing to add argument '%s'; provide an 'IsSet', 'Store' or 'Count' field", a.Name)
+	if a.IsSet == nil && a.Store == nil && a.Count == nil && a.Action == nil {
+		return nil, fmt.Errorf("refusing to add argument '%s'; provide an 'IsSet', 'Store', 'Count' or 'Action' field", a.Name)
 	}
 
 	return r, nil
 }
 
 type EnvVar struct {
-	Name    string
-	Help    string
-	Env     string
-	Default string
-	Flags   Flags // TODO: Test required for env
+	Name       string
+	Help       string
+	Env        string
+	Default    string
+	Flags      Flags // TODO: Test required for env
+	Validators []Validator
 
 	Store interface{}
 	IsSet *bool
@@ -174,10 +219,11 @@ func (e *EnvVar) toRule() (*rule, error) {
 	}
 
 	r := &rule{
-		Name:    e.Name,
-		HelpMsg: e.Help,
-		EnvVar:  e.Env,
-		Flags:   e.Flags,
+		Name:       e.Name,
+		HelpMsg:    e.Help,
+		EnvVar:     e.Env,
+		Flags:      e.Flags,
+		Validators: e.Validators,
 	}
 
 	if r.EnvVar == "" {
@@ -198,6 +244,7 @@ func (e *EnvVar) toRule() (*rule, error) {
 
 	if e.IsSet != nil {
 		r.StoreFuncs = append(r.StoreFuncs, toSet(e.IsSet))
+		r.Markers = append(r.Markers, e.IsSet)
 	}
 
 	if e.IsSet == nil && e.Store == nil {
@@ -207,34 +254,62 @@ func (e *EnvVar) toRule() (*rule, error) {
 	return r, nil
 }
 
+// A sub command selected by name on the command line. When matched, Parse() calls 'Func' with a
+// sub parser which inherits the options and stores of the parent. The sub parser's options are
+// added and parsed by 'Func', which should return the result of calling Parse() on the sub parser.
+//
+//   p.Add(&cli.Command{Name: "run", Help: "run an image", Func: func(ctx context.Context, sub *cli.Parser) (int, error) {
+//       sub.Add(&cli.Option{Name: "image", Store: &image})
+//       return sub.Parse(ctx, nil)
+//   }})
 type Command struct {
 	Name string
 	Help string
 	Func CommandFunc
 }
 
-func (a *Command) toRule() *rule {
+func (a *Command) name() string {
+	return a.Name
+}
+
+func (a *Command) toRule() (*rule, error) {
+	if a.Func == nil {
+		return nil, fmt.Errorf("refusing to add command '%s'; provide a 'Func' field", a.Name)
+	}
+	// The '[global]' section of an INI file holds the values of the root parser
+	if a.Name == iniGlobalSection {
+		return nil, fmt.Errorf("refusing to add command '%s'; the name is reserved for the "+
+			"'[%s]' INI section", a.Name, iniGlobalSection)
+	}
+
 	r := &rule{
 		Name:        subCmdNamePrefix + a.Name,
 		HelpMsg:     a.Help,
 		CommandFunc: a.Func,
 	}
 	r.SetFlag(isCommand, true)
-	return r
+	return r, nil
 }
 
 func (p *Parser) Add(variants ...Variant) {
+	p.add(2, variants...)
+}
+
+// Adds the variants to the parser, 'skip' is the number of stack frames to ascend
+// when reporting the file and line number of the caller who added the variants
+func (p *Parser) add(skip int, variants ...Variant) {
 	for _, v := range variants {
 		rule, err := v.toRule()
 		if err != nil {
 			// Extract the line number and file name that called 'Add'
-			_, file, line, _ := runtime.Caller(1)
+			_, file, line, _ := runtime.Caller(skip)
 			// Add the error to the parser, to be reported when `Parse()` is called
 			p.errs = append(p.errs, fmt.Errorf("%s:%d - %s", file, line, err))
 			return
 		}
 		rule.Sequence = p.seqCount
 		p.seqCount++
+		rule.TimeLayouts, rule.Now = p.cfg.TimeLayouts, p.cfg.Now
 
 		// Ensure arguments and commands are at the bottom of the rules when sorted by sequence
 		// TODO: Sorting the rules might not matter anymore, find out
@@ -266,6 +341,7 @@ func (p *Parser) Replace(variants ...Variant) error {
 
 		// Preserve the sequence and replace the rule
 		rule.Sequence = p.rules[idx].Sequence
+		rule.TimeLayouts, rule.Now = p.cfg.TimeLayouts, p.cfg.Now
 		p.rules[idx] = rule
 
 		// Any previously parsed abstract is now invalid
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A size in bytes which can be provided as a number with an optional SI (KB, MB, GB, ...) or
+// IEC (KiB, MiB, GiB, ...) unit such as '10MiB' or '2G'. SI units are powers of 1000, IEC units
+// are powers of 1024. Units are case insensitive, 'K', 'M' and 'G' are shorthand for KB, MB and GB.
+type ByteSize uint64
+
+const (
+	Byte ByteSize = 1
+	KB            = 1000 * Byte
+	MB            = 1000 * KB
+	GB            = 1000 * MB
+	TB            = 1000 * GB
+	PB            = 1000 * TB
+	EB            = 1000 * PB
+
+	KiB = 1024 * Byte
+	MiB = 1024 * KiB
+	GiB = 1024 * MiB
+	TiB = 1024 * GiB
+	PiB = 1024 * TiB
+	EiB = 1024 * PiB
+)
+
+// A rate in bytes per second which can be provided as a byte size with an optional '/s' suffix
+// such as '10MB/s' or '1GiB/s'
+type ByteRate ByteSize
+
+type byteUnit struct {
+	name string
+	size ByteSize
+}
+
+// Ordered from largest to smallest such that String() uses the largest unit which represents
+// the size exactly, preferring IEC units when both represent the size exactly
+var byteUnits = []byteUnit{
+	{"EiB", EiB}, {"EB", EB},
+	{"PiB", PiB}, {"PB", PB},
+	{"TiB", TiB}, {"TB", TB},
+	{"GiB", GiB}, {"GB", GB},
+	{"MiB", MiB}, {"MB", MB},
+	{"KiB", KiB}, {"KB", KB},
+}
+
+// Maps lower case unit suffixes to their size
+var byteSuffixes = map[string]ByteSize{
+	"": Byte, "b": Byte,
+	"k": KB, "kb": KB, "ki": KiB, "kib": KiB,
+	"m": MB, "mb": MB, "mi": MiB, "mib": MiB,
+	"g": GB, "gb": GB, "gi": GiB, "gib": GiB,
+	"t": TB, "tb": TB, "ti": TiB, "tib": TiB,
+	"p": PB, "pb": PB, "pi": PiB, "pib": PiB,
+	"e": EB, "eb": EB, "ei": EiB, "eib": EiB,
+}
+
+// Returns the size in human form such as '10MiB' or '2GB'. Sizes which cannot be represented
+// exactly by any unit are rounded to two decimal places of the largest IEC unit; IE: '1.46KiB'
+func (b ByteSize) String() string {
+	for _, unit := range byteUnits {
+		if b >= unit.size && b%unit.size == 0 {
+			return fmt.Sprintf("%d%s", b/unit.size, unit.name)
+		}
+	}
+	for _, unit := range byteUnits {
+		if b >= unit.size && unit.size%KiB == 0 {
+			value := strconv.FormatFloat(float64(b)/float64(unit.size), 'f', 2, 64)
+			return strings.TrimRight(strings.TrimRight(value, "0"), ".") + unit.name
+		}
+	}
+	return fmt.Sprintf("%dB", uint64(b))
+}
+
+// Returns the rate in human form such as '10MB/s'
+func (r ByteRate) String() string {
+	return ByteSize(r).String() + "/s"
+}
+
+// Parses a byte size such as '10MiB', '2G' or '1.5 KB'. The size must be a whole number of
+// bytes, such that '1.5KiB' is valid but '0.1' is not
+func ParseByteSize(value string) (ByteSize, error) {
+	value = strings.TrimSpace(value)
+	end := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if end == -1 {
+		end = len(value)
+	}
+
+	unit, ok := byteSuffixes[strings.ToLower(strings.TrimSpace(value[end:]))]
+	whole, fraction, hasFraction := strings.Cut(value[:end], ".")
+	if !ok || (whole == "" && fraction == "") || strings.Contains(fraction, ".") {
+		return 0, fmt.Errorf("'%s' is not a valid byte size; expected a number with an "+
+			"optional unit such as '10MB' or '10MiB'", value)
+	}
+
+	if !hasFraction {
+		number, err := strconv.ParseUint(whole, 10, 64)
+		if err != nil || number > math.MaxUint64/uint64(unit) {
+			return 0, fmt.Errorf("'%s' is too large; byte sizes must be less than 16EiB", value)
+		}
+		return ByteSize(number) * unit, nil
+	}
+
+	// Compute (whole.fraction * unit) exactly as (wholefraction * unit) / 10^len(fraction)
+	number, _ := new(big.Int).SetString(whole+fraction, 10)
+	number.Mul(number, new(big.Int).SetUint64(uint64(unit)))
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(len(fraction))), nil)
+	size, rem := number.QuoRem(number, scale, new(big.Int))
+	if rem.Sign() != 0 {
+		return 0, fmt.Errorf("'%s' is not a whole number of bytes", value)
+	}
+	if !size.IsUint64() {
+		return 0, fmt.Errorf("'%s' is too large; byte sizes must be less than 16EiB", value)
+	}
+	return ByteSize(size.Uint64()), nil
+}
+
+// Parses a byte rate such as '10MB/s' or '1GiB'
+func ParseByteRate(value string) (ByteRate, error) {
+	size, err := ParseByteSize(strings.TrimSuffix(strings.TrimSpace(value), "/s"))
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a valid byte rate; expected a byte size with an "+
+			"optional '/s' suffix such as '10MB/s'", value)
+	}
+	return ByteRate(size), nil
+}
+
+func parseByteSize(value string) (interface{}, error) {
+	return ParseByteSize(value)
+}
+
+func parseByteRate(value string) (interface{}, error) {
+	return ParseByteRate(value)
+}
+
+// Creates the StoreFunc for an 'int64' or 'uint64' scalar, slice or map destination of
+// a rule with the `ByteUnits` flag
+func newByteUnitsStoreFunc(r *rule, dest interface{}, d reflect.Value) error {
+	kind, elem := ScalarKind, d.Type()
+	switch elem.Kind() {
+	case reflect.Slice:
+		kind, elem = SliceKind, elem.Elem()
+	case reflect.Map:
+		if elem.Key().Kind() != reflect.String {
+			return fmt.Errorf("cannot use '%s'; only string keys are supported", elem.String())
+		}
+		kind, elem = MapKind, elem.Elem()
+	}
+
+	if elem.Kind() != reflect.Int64 && elem.Kind() != reflect.Uint64 {
+		return fmt.Errorf("cannot store '%s' with the ByteUnits flag; only int64 and uint64 are supported",
+			d.Type().String())
+	}
+
+	parse := func(value string) (interface{}, error) {
+		size, err := ParseByteSize(value)
+		if err != nil {
+			return nil, err
+		}
+		if elem.Kind() == reflect.Int64 && size > math.MaxInt64 {
+			return nil, fmt.Errorf("'%s' is too large; must be less than 8EiB", value)
+		}
+		return size, nil
+	}
+
+	switch kind {
+	case SliceKind:
+		r.StoreFuncs = append(r.StoreFuncs, sliceParser(parse)(dest))
+		r.Usage = "<size>,<size>"
+	case MapKind:
+		r.StoreFuncs = append(r.StoreFuncs, mapParser(parse)(dest))
+		r.Usage = "<string>=<size>"
+	default:
+		r.StoreFuncs = append(r.StoreFuncs, scalarParser(parse)(dest))
+		r.Usage = "<size>"
+		if !IsZeroValue(d) {
+			r.DisplayDefault = formatByteUnits(d)
+		}
+	}
+	r.SetFlag(kind, true)
+	r.Dests = append(r.Dests, dest)
+	return nil
+}
+
+// Formats the 'int64' or 'uint64' value as a byte size, preserving the sign of negative values
+func formatByteUnits(v reflect.Value) string {
+	if v.Kind() == reflect.Uint64 {
+		return ByteSize(v.Uint()).String()
+	}
+	n := v.Int()
+	if n < 0 {
+		// Avoids overflow when negating math.MinInt64
+		return "-" + ByteSize(uint64(-(n+1))+1).String()
+	}
+	return ByteSize(n).String()
+}
@@ -2,7 +2,12 @@ package cli
 
 import (
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -154,7 +159,7 @@ func toIntMap(o interface{}) StoreFunc {
 func toUint(o interface{}) StoreFunc {
 	ptr := o.(*uint)
 	return func(value interface{}, count int) error {
-		i, err := strconv.ParseInt(value.(string), 0, strconv.IntSize)
+		i, err := strconv.ParseUint(value.(string), 0, strconv.IntSize)
 		if err != nil {
 			return fmt.Errorf("'%s' is not an integer", value.(string))
 		}
@@ -168,7 +173,7 @@ func toUintSlice(o interface{}) StoreFunc {
 	return func(value interface{}, count int) error {
 		var r []uint
 		for _, item := range value.([]string) {
-			i, err := strconv.ParseInt(strings.TrimSpace(item), 0, strconv.IntSize)
+			i, err := strconv.ParseUint(strings.TrimSpace(item), 0, strconv.IntSize)
 			if err != nil {
 				return fmt.Errorf("'%s' is not an integer", item)
 			}
@@ -185,7 +190,7 @@ func toUintMap(o interface{}) StoreFunc {
 		strMap := value.(map[string]string)
 		result := make(map[string]uint, len(strMap))
 		for k, v := range strMap {
-			i, err := strconv.ParseInt(strings.TrimSpace(v), 0, strconv.IntSize)
+			i, err := strconv.ParseUint(strings.TrimSpace(v), 0, strconv.IntSize)
 			if err != nil {
 				return fmt.Errorf("'%s' is not an integer", v)
 			}
@@ -248,11 +253,11 @@ func toInt64Map(o interface{}) StoreFunc {
 func toUint64(o interface{}) StoreFunc {
 	ptr := o.(*uint64)
 	return func(value interface{}, count int) error {
-		i, err := strconv.ParseInt(value.(string), 0, 64)
+		i, err := strconv.ParseUint(value.(string), 0, 64)
 		if err != nil {
 			return fmt.Errorf("'%s' is not an integer", value.(string))
 		}
-		*ptr = uint64(i)
+		*ptr = i
 		return nil
 	}
 }
@@ -262,11 +267,11 @@ func toUint64Slice(o interface{}) StoreFunc {
 	return func(value interface{}, count int) error {
 		var r []uint64
 		for _, item := range value.([]string) {
-			i, err := strconv.ParseInt(strings.TrimSpace(item), 0, 64)
+			i, err := strconv.ParseUint(strings.TrimSpace(item), 0, 64)
 			if err != nil {
 				return fmt.Errorf("'%s' is not an integer", item)
 			}
-			r = append(r, uint64(i))
+			r = append(r, i)
 		}
 		*ptr = r
 		return nil
@@ -279,11 +284,11 @@ func toUint64Map(o interface{}) StoreFunc {
 		strMap := value.(map[string]string)
 		result := make(map[string]uint64, len(strMap))
 		for k, v := range strMap {
-			i, err := strconv.ParseInt(strings.TrimSpace(v), 0, 64)
+			i, err := strconv.ParseUint(strings.TrimSpace(v), 0, 64)
 			if err != nil {
 				return fmt.Errorf("'%s' is not an integer", v)
 			}
-			result[k] = uint64(i)
+			result[k] = i
 		}
 		*ptr = result
 		return nil
@@ -342,7 +347,7 @@ func toFloat64Map(o interface{}) StoreFunc {
 func toDuration(o interface{}) StoreFunc {
 	ptr := o.(*time.Duration)
 	return func(value interface{}, count int) error {
-		i, err := time.ParseDuration(value.(string))
+		i, err := ParseDuration(value.(string))
 		if err != nil {
 			return fmt.Errorf("'%s' is not a valid duration", value.(string))
 		}
@@ -356,7 +361,7 @@ func toDurationSlice(o interface{}) StoreFunc {
 	return func(value interface{}, count int) error {
 		var r []time.Duration
 		for _, item := range value.([]string) {
-			i, err := time.ParseDuration(strings.TrimSpace(item))
+			i, err := ParseDuration(strings.TrimSpace(item))
 			if err != nil {
 				return fmt.Errorf("'%s' is not a valid duration", item)
 			}
@@ -373,7 +378,7 @@ func toDurationMap(o interface{}) StoreFunc {
 		strMap := value.(map[string]string)
 		result := make(map[string]time.Duration, len(strMap))
 		for k, v := range strMap {
-			i, err := time.ParseDuration(strings.TrimSpace(v))
+			i, err := ParseDuration(strings.TrimSpace(v))
 			if err != nil {
 				return fmt.Errorf("'%s' is not a valid duration", v)
 			}
@@ -393,6 +398,22 @@ var scalars = map[reflect.Type]func(interface{}) StoreFunc{
 	reflect.TypeOf(uint64(0)):        toUint64,
 	reflect.TypeOf(float64(0)):       toFloat64,
 	reflect.TypeOf(time.Duration(0)): toDuration,
+	reflect.TypeOf(net.IP{}):         scalarParser(parseIP),
+	reflect.TypeOf(&net.IPNet{}):     scalarParser(parseIPNet),
+	reflect.TypeOf(&url.URL{}):       scalarParser(parseURL),
+	reflect.TypeOf(netip.AddrPort{}): scalarParser(parseAddrPort),
+	reflect.TypeOf(HostPort{}):       scalarParser(parseHostPort),
+	reflect.TypeOf(ByteSize(0)):      scalarParser(parseByteSize),
+	reflect.TypeOf(ByteRate(0)):      scalarParser(parseByteRate),
+	reflect.TypeOf(time.Time{}):      scalarParser(parseTime),
+	reflect.TypeOf(int8(0)):          scalarParser(parseIntBits(8, "int8")),
+	reflect.TypeOf(int16(0)):         scalarParser(parseIntBits(16, "int16")),
+	reflect.TypeOf(int32(0)):         scalarParser(parseIntBits(32, "int32")),
+	reflect.TypeOf(uint8(0)):         scalarParser(parseUintBits(8, "uint8")),
+	reflect.TypeOf(uint16(0)):        scalarParser(parseUintBits(16, "uint16")),
+	reflect.TypeOf(uint32(0)):        scalarParser(parseUintBits(32, "uint32")),
+	reflect.TypeOf(float32(0)):       scalarParser(parseFloat32),
+	reflect.TypeOf(os.FileMode(0)):   scalarParser(parseFileMode),
 }
 
 var slices = map[reflect.Type]func(interface{}) StoreFunc{
@@ -404,6 +425,22 @@ var slices = map[reflect.Type]func(interface{}) StoreFunc{
 	reflect.TypeOf(uint64(0)):        toUint64Slice,
 	reflect.TypeOf(float64(0)):       toFloat64Slice,
 	reflect.TypeOf(time.Duration(0)): toDurationSlice,
+	reflect.TypeOf(net.IP{}):         sliceParser(parseIP),
+	reflect.TypeOf(&net.IPNet{}):     sliceParser(parseIPNet),
+	reflect.TypeOf(&url.URL{}):       sliceParser(parseURL),
+	reflect.TypeOf(netip.AddrPort{}): sliceParser(parseAddrPort),
+	reflect.TypeOf(HostPort{}):       sliceParser(parseHostPort),
+	reflect.TypeOf(ByteSize(0)):      sliceParser(parseByteSize),
+	reflect.TypeOf(ByteRate(0)):      sliceParser(parseByteRate),
+	reflect.TypeOf(time.Time{}):      sliceParser(parseTime),
+	reflect.TypeOf(int8(0)):          sliceParser(parseIntBits(8, "int8")),
+	reflect.TypeOf(int16(0)):         sliceParser(parseIntBits(16, "int16")),
+	reflect.TypeOf(int32(0)):         sliceParser(parseIntBits(32, "int32")),
+	reflect.TypeOf(uint8(0)):         sliceParser(parseUintBits(8, "uint8")),
+	reflect.TypeOf(uint16(0)):        sliceParser(parseUintBits(16, "uint16")),
+	reflect.TypeOf(uint32(0)):        sliceParser(parseUintBits(32, "uint32")),
+	reflect.TypeOf(float32(0)):       sliceParser(parseFloat32),
+	reflect.TypeOf(os.FileMode(0)):   sliceParser(parseFileMode),
 }
 
 var maps = map[reflect.Type]func(interface{}) StoreFunc{
@@ -415,23 +452,94 @@ var maps = map[reflect.Type]func(interface{}) StoreFunc{
 	reflect.TypeOf(uint64(0)):        toUint64Map,
 	reflect.TypeOf(float64(0)):       toFloat64Map,
 	reflect.TypeOf(time.Duration(0)): toDurationMap,
+	reflect.TypeOf(net.IP{}):         mapParser(parseIP),
+	reflect.TypeOf(&net.IPNet{}):     mapParser(parseIPNet),
+	reflect.TypeOf(&url.URL{}):       mapParser(parseURL),
+	reflect.TypeOf(netip.AddrPort{}): mapParser(parseAddrPort),
+	reflect.TypeOf(HostPort{}):       mapParser(parseHostPort),
+	reflect.TypeOf(ByteSize(0)):      mapParser(parseByteSize),
+	reflect.TypeOf(ByteRate(0)):      mapParser(parseByteRate),
+	reflect.TypeOf(time.Time{}):      mapParser(parseTime),
+	reflect.TypeOf(int8(0)):          mapParser(parseIntBits(8, "int8")),
+	reflect.TypeOf(int16(0)):         mapParser(parseIntBits(16, "int16")),
+	reflect.TypeOf(int32(0)):         mapParser(parseIntBits(32, "int32")),
+	reflect.TypeOf(uint8(0)):         mapParser(parseUintBits(8, "uint8")),
+	reflect.TypeOf(uint16(0)):        mapParser(parseUintBits(16, "uint16")),
+	reflect.TypeOf(uint32(0)):        mapParser(parseUintBits(32, "uint32")),
+	reflect.TypeOf(float32(0)):       mapParser(parseFloat32),
+	reflect.TypeOf(os.FileMode(0)):   mapParser(parseFileMode),
+}
+
+// The placeholder displayed in help for types whose name would not be helpful to the user
+var usages = map[reflect.Type]string{
+	reflect.TypeOf(net.IP{}):         "ip",
+	reflect.TypeOf(&net.IPNet{}):     "cidr",
+	reflect.TypeOf(&url.URL{}):       "url",
+	reflect.TypeOf(netip.AddrPort{}): "ip:port",
+	reflect.TypeOf(HostPort{}):       "host:port",
+	reflect.TypeOf(ByteSize(0)):      "size",
+	reflect.TypeOf(ByteRate(0)):      "rate",
+	reflect.TypeOf(time.Time{}):      "time",
+	reflect.TypeOf(os.FileMode(0)):   "mode",
+}
+
+// Returns a StoreFunc which calls Set() on the SetValue provided, the optional `BoolValue`,
+// `ScalarValue`, `TypedValue` and `fmt.Stringer` interfaces determine the kind and usage of the rule
+func toSetValue(r *rule, sv SetValue) StoreFunc {
+	r.Usage = "<string>"
+	if t, ok := sv.(TypedValue); ok {
+		r.Usage = fmt.Sprintf("<%s>", t.Type())
+	}
+	if s, ok := sv.(fmt.Stringer); ok {
+		r.DisplayDefault = s.String()
+	}
+
+	if b, ok := sv.(BoolValue); ok && b.IsBoolFlag() {
+		r.SetFlag(isExpectingValue, false)
+		r.SetFlag(ScalarKind, true)
+		r.Usage = ""
+		return func(value interface{}, count int) error {
+			// Options provided on the command line have no value
+			if value == nil {
+				return sv.Set("true")
+			}
+			return sv.Set(value.(string))
+		}
+	}
+
+	if s, ok := sv.(ScalarValue); ok && s.IsScalar() {
+		r.SetFlag(ScalarKind, true)
+		return func(value interface{}, count int) error {
+			return sv.Set(value.(string))
+		}
+	}
+
+	r.SetFlag(SliceKind, true)
+	return func(value interface{}, count int) error {
+		values := value.([]string)
+		for _, v := range values {
+			if err := sv.Set(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 }
 
 func newStoreFunc(r *rule, dest interface{}) error {
+	// Optional destinations such as '**int' are left nil until a value is stored
+	if isOptional(dest) {
+		return newOptionalStoreFunc(r, dest)
+	}
+
+	if r.Cast != nil {
+		return newCastStoreFunc(r, dest)
+	}
+
 	// If the dest conforms to the SetValue interface
 	if sv, ok := dest.(SetValue); ok {
-		fn := func(value interface{}, count int) error {
-			values := value.([]string)
-			for _, v := range values {
-				if err := sv.Set(v); err != nil {
-					return err
-				}
-			}
-			return nil
-		}
-		r.SetFlag(SliceKind, true)
-		r.StoreFuncs = append(r.StoreFuncs, fn)
-		r.Usage = "<string>"
+		r.StoreFuncs = append(r.StoreFuncs, toSetValue(r, sv))
+		r.Dests = append(r.Dests, dest)
 		return nil
 	}
 
@@ -443,19 +551,53 @@ func newStoreFunc(r *rule, dest interface{}) error {
 	// Dereference the pointer
 	d = reflect.Indirect(d)
 
-	// Determine if it's a scalar, slice or map
+	if len(r.Choices) != 0 {
+		return newChoiceStoreFunc(r, dest, d)
+	}
+
+	if d.Type() == bytesType {
+		return newBytesStoreFunc(r, dest)
+	}
+
+	if r.HasFlag(ByteUnits) {
+		return newByteUnitsStoreFunc(r, dest, d)
+	}
+
+	if isTimeType(d.Type()) {
+		return newTimeStoreFunc(r, dest, d)
+	}
+
+	// Scalars are checked first as some scalar types such as `net.IP` are slices
+	if fn, ok := scalars[d.Type()]; ok {
+		r.SetFlag(ScalarKind, true)
+		r.StoreFuncs = append(r.StoreFuncs, fn(dest))
+		r.Dests = append(r.Dests, dest)
+		r.Usage = fmt.Sprintf("<%s>", typeUsage(d.Type(), d.Kind().String()))
+
+		// Display the initial value of byte sizes in human form
+		switch v := d.Interface().(type) {
+		case ByteSize, ByteRate:
+			if !IsZeroValue(d) {
+				r.DisplayDefault = v.(fmt.Stringer).String()
+			}
+		}
+		return nil
+	}
+
+	// Determine if it's a slice or map
 	switch d.Kind() {
 	case reflect.Slice:
 		elem := reflect.TypeOf(dest).Elem().Elem()
 		fn, ok := slices[elem]
 		if !ok {
 			return fmt.Errorf("cannot store '[]%s'; only "+
-				"'%s' currently supported", elem.Kind(), supportedSlices())
+				"'%s' currently supported", elem.String(), supportedSlices())
 		}
 
 		r.SetFlag(SliceKind, true)
 		r.StoreFuncs = append(r.StoreFuncs, fn(dest))
-		r.Usage = fmt.Sprintf("<%[1]s>,<%[1]s>", elem.String())
+		r.Dests = append(r.Dests, dest)
+		r.Usage = fmt.Sprintf("<%[1]s>,<%[1]s>", typeUsage(elem, elem.String()))
 		return nil
 	case reflect.Map:
 		elem := d.Type().Elem()
@@ -463,49 +605,234 @@ func newStoreFunc(r *rule, dest interface{}) error {
 
 		if key.Kind() != reflect.String {
 			return fmt.Errorf("cannot use 'map[%s]%s'; only "+
-				"'%s' currently supported", key.Kind(), elem.Kind(), supportedMaps())
+				"'%s' currently supported", key.String(), elem.String(), supportedMaps())
 		}
 
 		fn, ok := maps[elem]
 		if !ok {
 			return fmt.Errorf("cannot use 'map[%s]%s'; only "+
-				"'%s' currently supported", key.Kind(), elem.Kind(), supportedMaps())
+				"'%s' currently supported", key.String(), elem.String(), supportedMaps())
 		}
 
 		r.SetFlag(MapKind, true)
 		r.StoreFuncs = append(r.StoreFuncs, fn(dest))
-		r.Usage = fmt.Sprintf("<string>=<%s>", elem.String())
+		r.Dests = append(r.Dests, dest)
+		r.Usage = fmt.Sprintf("<string>=<%s>", typeUsage(elem, elem.String()))
 		return nil
 	}
 
-	// Handle Scalars
-	fn, ok := scalars[d.Type()]
-	if !ok {
-		// Slightly less confusing error for those attempting to use arrays
-		if d.Kind() == reflect.Array {
-			return fmt.Errorf("cannot store '%s'; only slices supported", d.Type().String())
+	// Slightly less confusing error for those attempting to use arrays
+	if d.Kind() == reflect.Array {
+		return fmt.Errorf("cannot store '%s'; only slices supported", d.Type().String())
+	}
+	return fmt.Errorf("cannot store '%s'; type not supported, only '%s' currently supported",
+		d.Type().String(), supportedScalars())
+}
+
+// Returns the usage placeholder for the type if one is registered, else the fallback provided
+func typeUsage(t reflect.Type, fallback string) string {
+	if usage, ok := usages[t]; ok {
+		return usage
+	}
+	return fallback
+}
+
+// Returns a function which creates a StoreFunc that converts a `ScalarKind` value using 'parse'
+func scalarParser(parse func(string) (interface{}, error)) func(interface{}) StoreFunc {
+	return func(o interface{}) StoreFunc {
+		ptr := reflect.ValueOf(o).Elem()
+		return func(value interface{}, count int) error {
+			v, err := parse(value.(string))
+			if err != nil {
+				return err
+			}
+			ptr.Set(reflect.ValueOf(v).Convert(ptr.Type()))
+			return nil
+		}
+	}
+}
+
+// Returns a function which creates a StoreFunc that converts each item of a `SliceKind` value using 'parse'
+func sliceParser(parse func(string) (interface{}, error)) func(interface{}) StoreFunc {
+	return func(o interface{}) StoreFunc {
+		ptr := reflect.ValueOf(o).Elem()
+		return func(value interface{}, count int) error {
+			items := value.([]string)
+			result := reflect.MakeSlice(ptr.Type(), 0, len(items))
+			for _, item := range items {
+				v, err := parse(strings.TrimSpace(item))
+				if err != nil {
+					return err
+				}
+				result = reflect.Append(result, reflect.ValueOf(v).Convert(ptr.Type().Elem()))
+			}
+			ptr.Set(result)
+			return nil
 		}
-		return fmt.Errorf("cannot store '%s'; type not supported", d.Type().String())
 	}
+}
 
-	r.SetFlag(ScalarKind, true)
-	r.StoreFuncs = append(r.StoreFuncs, fn(dest))
-	r.Usage = fmt.Sprintf("<%s>", d.Kind().String())
+// Returns a function which creates a StoreFunc that converts each value of a `MapKind` value using 'parse'
+func mapParser(parse func(string) (interface{}, error)) func(interface{}) StoreFunc {
+	return func(o interface{}) StoreFunc {
+		ptr := reflect.ValueOf(o).Elem()
+		return func(value interface{}, count int) error {
+			strMap := value.(map[string]string)
+			result := reflect.MakeMapWithSize(ptr.Type(), len(strMap))
+			for k, v := range strMap {
+				item, err := parse(strings.TrimSpace(v))
+				if err != nil {
+					return err
+				}
+				result.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(item).Convert(ptr.Type().Elem()))
+			}
+			ptr.Set(result)
+			return nil
+		}
+	}
+}
+
+// Creates a StoreFunc which converts the value using the rule's `CastFunc`. Slices and maps
+// have each item converted unless the rule has the `ScalarKind` flag
+func newCastStoreFunc(r *rule, dest interface{}) error {
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr {
+		return fmt.Errorf("cannot use non pointer type '%s'; must provide a pointer", reflect.TypeOf(dest))
+	}
+
+	kind, elem := ScalarKind, d.Elem().Type()
+	if !r.HasFlag(ScalarKind) {
+		switch elem.Kind() {
+		case reflect.Slice:
+			kind, elem = SliceKind, elem.Elem()
+		case reflect.Map:
+			if elem.Key().Kind() != reflect.String {
+				return fmt.Errorf("cannot use '%s'; only string keys are supported", elem.String())
+			}
+			kind, elem = MapKind, elem.Elem()
+		}
+	}
+
+	parse := checkedCast(r.Cast, elem)
+	usage := typeUsage(elem, strings.ToLower(elem.Name()))
+	if usage == "" {
+		usage = elem.String()
+	}
+
+	switch kind {
+	case SliceKind:
+		r.StoreFuncs = append(r.StoreFuncs, sliceParser(parse)(dest))
+		r.Usage = fmt.Sprintf("<%[1]s>,<%[1]s>", usage)
+	case MapKind:
+		r.StoreFuncs = append(r.StoreFuncs, mapParser(parse)(dest))
+		r.Usage = fmt.Sprintf("<string>=<%s>", usage)
+	default:
+		r.StoreFuncs = append(r.StoreFuncs, scalarParser(parse)(dest))
+		r.Usage = fmt.Sprintf("<%s>", usage)
+	}
+	r.SetFlag(kind, true)
+	r.Dests = append(r.Dests, dest)
 	return nil
 }
 
+// Creates a StoreFunc for an optional destination such as '**int' which stores a pointer to a new
+// value each time a value is stored. The pointer remains nil if no value is provided.
+func newOptionalStoreFunc(r *rule, dest interface{}) error {
+	ptr := reflect.ValueOf(dest).Elem()
+	elem := ptr.Type().Elem()
+
+	// Create a StoreFunc for the underlying type to determine the kind and usage of the rule
+	inner := r.derive()
+	if err := newStoreFunc(inner, reflect.New(elem).Interface()); err != nil {
+		return err
+	}
+	r.Flags = inner.Flags
+	r.Usage = inner.Usage
+
+	r.StoreFuncs = append(r.StoreFuncs, func(value interface{}, count int) error {
+		v := reflect.New(elem)
+		fresh := r.derive()
+		if err := newStoreFunc(fresh, v.Interface()); err != nil {
+			return err
+		}
+		if err := fresh.StoreValue(value, count); err != nil {
+			return err
+		}
+		ptr.Set(v)
+		return nil
+	})
+	r.Dests = append(r.Dests, dest)
+	return nil
+}
+
+// Returns true if 'dest' is an optional destination such as '**int'
+func isOptional(dest interface{}) bool {
+	t := reflect.TypeOf(dest)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Ptr {
+		return false
+	}
+	_, ok := scalars[t.Elem()]
+	return !ok
+}
+
+// Returns a parse function which calls 'cast' and ensures the value returned can be stored in 't'
+func checkedCast(cast CastFunc, t reflect.Type) func(string) (interface{}, error) {
+	return func(value string) (interface{}, error) {
+		v, err := cast(value)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil || !reflect.TypeOf(v).ConvertibleTo(t) {
+			return nil, fmt.Errorf("CastFunc returned '%T'; expected '%s'", v, t.String())
+		}
+		return v, nil
+	}
+}
+
+// Registers a type such that it can be used as the 'Store' of any rule along with slices and
+// maps of the type. 'parse' converts the raw value provided by the user into the type, 'usage'
+// is the placeholder displayed in help. Registering a type which is already supported replaces
+// the existing cast, except for `[]byte`, `time.Time` and rules with the `ByteUnits` flag which
+// are always converted according to the rule's flags and the parser's config; use `Cast` on
+// the rule to override those. RegisterType() is not safe to call concurrently with Add() or
+// Parse() and should be called from an init() function.
+//
+//   cli.RegisterType(reflect.TypeOf(semver.Version{}), func(value string) (interface{}, error) {
+//       return semver.Parse(value)
+//   }, "version")
+func RegisterType(t reflect.Type, parse CastFunc, usage string) {
+	checked := checkedCast(parse, t)
+	scalars[t] = scalarParser(checked)
+	slices[t] = sliceParser(checked)
+	maps[t] = mapParser(checked)
+	if usage != "" {
+		usages[t] = usage
+	}
+}
+
+func supportedScalars() string {
+	var results []string
+	for k := range scalars {
+		results = append(results, k.String())
+	}
+	sort.Strings(results)
+	return strings.Join(results, ", ")
+}
+
 func supportedMaps() string {
 	var results []string
 	for k := range maps {
 		results = append(results, fmt.Sprintf("map[string]%s", k.String()))
 	}
+	sort.Strings(results)
 	return strings.Join(results, ", ")
 }
 
 func supportedSlices() string {
 	var results []string
-	for k := range maps {
+	for k := range slices {
 		results = append(results, fmt.Sprintf("[]%s", k.String()))
 	}
+	sort.Strings(results)
 	return strings.Join(results, ", ")
 }
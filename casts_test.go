@@ -3,7 +3,11 @@ package cli_test
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,7 +21,7 @@ import (
 )
 
 func TestInvalidStoreType(t *testing.T) {
-	var integer32 uint32
+	var number complex64
 	var integer int
 	var aInt [2]int
 
@@ -26,8 +30,9 @@ func TestInvalidStoreType(t *testing.T) {
 		err string
 	}{
 		{
-			opt: &cli.Option{Name: "foo", Store: &integer32},
-			err: "invalid 'Store' while adding option 'foo': cannot store 'uint32'; type not supported",
+			opt: &cli.Option{Name: "foo", Store: &number},
+			err: "invalid 'Store' while adding option 'foo': cannot store 'complex64'; type not supported, " +
+				"only '*net.IPNet, *url.URL, bool, cli.ByteRate, cli.ByteSize,",
 		},
 		{
 			opt: &cli.Option{Name: "foo", Store: integer},
@@ -254,7 +259,7 @@ func TestOptionBoolMap(t *testing.T) {
 }
 
 func TestInvalidMapType(t *testing.T) {
-	var foo map[string]uint32
+	var foo map[string]complex64
 	var count int
 
 	p := cli.New(nil)
@@ -267,7 +272,7 @@ func TestInvalidMapType(t *testing.T) {
 	// Then
 	require.NotNil(t, err)
 	assert.Equal(t, cli.ErrorRetCode, retCode)
-	assert.Contains(t, err.Error(), "invalid 'Store' while adding option 'foo': cannot use 'map[string]uint32';")
+	assert.Contains(t, err.Error(), "invalid 'Store' while adding option 'foo': cannot use 'map[string]complex64';")
 }
 
 func TestOptionWithMapAndJSON(t *testing.T) {
@@ -365,6 +370,565 @@ func TestSetValueInterface(t *testing.T) {
 
 }
 
+type level struct {
+	value int
+	set   int
+}
+
+func (l *level) Set(v string) error {
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a level", v)
+	}
+	l.value = i
+	l.set++
+	return nil
+}
+
+func (l *level) String() string { return strconv.Itoa(l.value) }
+func (l *level) Type() string   { return "level" }
+func (l *level) IsScalar() bool { return true }
+
+type toggle struct {
+	on bool
+}
+
+func (t *toggle) Set(v string) error {
+	b, err := cli.ToBool(v)
+	t.on = b
+	return err
+}
+
+func (t *toggle) IsBoolFlag() bool { return true }
+
+func TestSetValueExtensions(t *testing.T) {
+	lvl := level{value: 3}
+	var debug toggle
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "level", Store: &lvl, Help: "the level"},
+		&cli.Option{Name: "debug", Env: "TEST_DEBUG", Store: &debug, Help: "enable debug"},
+	)
+
+	help := p.GenerateHelp()
+	assert.Contains(t, help, "--level <level>   the level (default=3)")
+	assert.Contains(t, help, "--debug           enable debug (env=TEST_DEBUG)")
+
+	// Scalar values are not split on commas
+	_, err := p.Parse(nil, []string{"--level", "1,2"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'level': '1,2' is not a level", err.Error())
+
+	// Bool flags expect no value
+	_, err = p.Parse(nil, []string{"--level", "5", "--debug"})
+	require.Nil(t, err)
+	assert.Equal(t, 5, lvl.value)
+	assert.True(t, debug.on)
+
+	// Bool flags accept a value from other sources
+	os.Setenv("TEST_DEBUG", "false")
+	defer os.Unsetenv("TEST_DEBUG")
+
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.False(t, debug.on)
+}
+
+func TestNetworkTypes(t *testing.T) {
+	var ip net.IP
+	var cidr *net.IPNet
+	var endpoint *url.URL
+	var listen netip.AddrPort
+	var peers []cli.HostPort
+	var gateways map[string]net.IP
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "bind", Store: &ip, Help: "bind address"},
+		&cli.Option{Name: "cidr", Store: &cidr, Help: "allowed network"},
+		&cli.Option{Name: "endpoint", Store: &endpoint, Help: "api endpoint"},
+		&cli.Option{Name: "listen", Store: &listen, Help: "listen address"},
+		&cli.Option{Name: "peers", Store: &peers, Help: "cluster peers"},
+		&cli.Option{Name: "gateways", Store: &gateways, Help: "gateway per zone"},
+	)
+
+	help := p.GenerateHelp()
+	assert.Contains(t, help, "--bind <ip>")
+	assert.Contains(t, help, "--cidr <cidr>")
+	assert.Contains(t, help, "--endpoint <url>")
+	assert.Contains(t, help, "--listen <ip:port>")
+	assert.Contains(t, help, "--peers <host:port>,<host:port>")
+	assert.Contains(t, help, "--gateways <string>=<ip>")
+
+	_, err := p.Parse(nil, []string{
+		"--bind", "::1",
+		"--cidr", "10.0.0.0/8",
+		"--endpoint", "https://example.com:8443/v1",
+		"--listen", "127.0.0.1:8080",
+		"--peers", "node1:7946, [::1]:7946",
+		"--gateways", "east=10.0.0.1,west=fd00::1",
+	})
+	require.Nil(t, err)
+	assert.Equal(t, net.ParseIP("::1"), ip)
+	assert.Equal(t, "10.0.0.0/8", cidr.String())
+	assert.Equal(t, "example.com:8443", endpoint.Host)
+	assert.Equal(t, netip.MustParseAddrPort("127.0.0.1:8080"), listen)
+	assert.Equal(t, []cli.HostPort{{Host: "node1", Port: 7946}, {Host: "::1", Port: 7946}}, peers)
+	assert.Equal(t, "[::1]:7946", peers[1].String())
+	assert.Equal(t, net.ParseIP("fd00::1"), gateways["west"])
+
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{
+			args: []string{"--bind", "10.0.0.256"},
+			err: "invalid value for option 'bind': '10.0.0.256' is not a valid IP address; " +
+				"expected '192.168.1.1' or '::1'",
+		},
+		{
+			args: []string{"--cidr", "10.0.0.0"},
+			err:  "invalid value for option 'cidr': '10.0.0.0' is not a valid CIDR; expected '10.0.0.0/8' or 'fd00::/8'",
+		},
+		{
+			args: []string{"--endpoint", "example.com"},
+			err:  "invalid value for option 'endpoint': 'example.com' is not a valid URL; expected 'scheme://host/path'",
+		},
+		{
+			args: []string{"--listen", "localhost:80"},
+			err: "invalid value for option 'listen': 'localhost:80' is not a valid IP and port; " +
+				"expected '127.0.0.1:80' or '[::1]:80'",
+		},
+		{
+			args: []string{"--peers", "node1:99999"},
+			err: "invalid value for option 'peers': 'node1:99999' is not a valid host and port; " +
+				"port must be between 0 and 65535",
+		},
+		{
+			args: []string{"--peers", "node1"},
+			err:  "invalid value for option 'peers': 'node1' is not a valid host and port; expected 'localhost:80'",
+		},
+	} {
+		_, err := p.Parse(nil, test.args)
+		require.NotNil(t, err)
+		assert.Equal(t, test.err, err.Error())
+	}
+}
+
+func TestByteSizes(t *testing.T) {
+	maxBody := 10 * cli.MiB
+	var cache int64
+	var limits []uint64
+	var quotas map[string]cli.ByteSize
+	var rate cli.ByteRate
+	var offset int64 = -2 << 20
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "max-body", Store: &maxBody, Help: "largest request body"},
+		&cli.Option{Name: "offset", Store: &offset, Flags: cli.ByteUnits, Help: "seek offset"},
+		&cli.Option{Name: "cache", Store: &cache, Flags: cli.ByteUnits, Help: "cache size"},
+		&cli.Option{Name: "limits", Store: &limits, Flags: cli.ByteUnits, Help: "size limits"},
+		&cli.Option{Name: "quotas", Store: &quotas, Help: "quota per user"},
+		&cli.Option{Name: "rate", Store: &rate, Default: "1MB/s", Help: "transfer rate"},
+	)
+
+	help := p.GenerateHelp()
+	assert.Contains(t, help, "--max-body <size>          largest request body (default=10MiB)")
+	assert.Contains(t, help, "--cache <size>             cache size")
+	assert.Contains(t, help, "--offset <size>            seek offset (default=-2MiB)")
+	assert.Contains(t, help, "--limits <size>,<size>     size limits")
+	assert.Contains(t, help, "--quotas <string>=<size>   quota per user")
+	assert.Contains(t, help, "--rate <rate>              transfer rate (default=1MB/s)")
+
+	_, err := p.Parse(nil, []string{
+		"--max-body", "1.5 KiB",
+		"--cache", "2G",
+		"--limits", "512,1k,1Ki",
+		"--quotas", "goku=9TB,vegeta=8TiB",
+	})
+	require.Nil(t, err)
+	assert.Equal(t, cli.ByteSize(1536), maxBody)
+	assert.Equal(t, int64(2000000000), cache)
+	assert.Equal(t, []uint64{512, 1000, 1024}, limits)
+	assert.Equal(t, 9*cli.TB, quotas["goku"])
+	assert.Equal(t, 8*cli.TiB, quotas["vegeta"])
+	assert.Equal(t, cli.ByteRate(cli.MB), rate)
+
+	for _, test := range []struct {
+		size cli.ByteSize
+		str  string
+	}{
+		{size: 0, str: "0B"},
+		{size: 100, str: "100B"},
+		{size: 1536, str: "1.5KiB"},
+		{size: 1500, str: "1.46KiB"},
+		{size: 3 * cli.GB, str: "3GB"},
+		{size: 1024 * cli.KiB, str: "1MiB"},
+	} {
+		assert.Equal(t, test.str, test.size.String())
+	}
+
+	_, err = p.Parse(nil, []string{"--max-body", "10MiBs"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'max-body': '10MiBs' is not a valid byte size; "+
+		"expected a number with an optional unit such as '10MB' or '10MiB'", err.Error())
+
+	// Sizes are parsed exactly and must be a whole number of bytes
+	_, err = p.Parse(nil, []string{"--max-body", "9007199254740993"})
+	require.Nil(t, err)
+	assert.Equal(t, cli.ByteSize(9007199254740993), maxBody)
+
+	_, err = p.Parse(nil, []string{"--max-body", "0.001KB"})
+	require.Nil(t, err)
+	assert.Equal(t, cli.ByteSize(1), maxBody)
+
+	_, err = p.Parse(nil, []string{"--max-body", "0.1"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'max-body': '0.1' is not a whole number of bytes", err.Error())
+
+	_, err = p.Parse(nil, []string{"--max-body", "16EiB"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'max-body': '16EiB' is too large; "+
+		"byte sizes must be less than 16EiB", err.Error())
+
+	_, err = p.Parse(nil, []string{"--cache", "9EiB"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'cache': '9EiB' is too large; must be less than 8EiB", err.Error())
+
+	_, err = p.Parse(nil, []string{"--rate", "10MB/m"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'rate': '10MB/m' is not a valid byte rate; "+
+		"expected a byte size with an optional '/s' suffix such as '10MB/s'", err.Error())
+
+	var count int32
+	p = cli.New(nil)
+	p.Add(&cli.Option{Name: "count", Store: &count, Flags: cli.ByteUnits})
+	_, err = p.Parse(nil, []string{})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "cannot store 'int32' with the ByteUnits flag; only int64 and uint64 are supported")
+}
+
+func TestTimeTypes(t *testing.T) {
+	now := time.Date(2018, time.June, 15, 13, 30, 0, 0, time.UTC)
+
+	var since, until time.Time
+	var window time.Duration
+	var marks []time.Time
+	var retention map[string]time.Duration
+
+	p := cli.New(&cli.Config{Now: func() time.Time { return now }})
+	p.Add(
+		&cli.Option{Name: "since", Store: &since, Help: "start of the query"},
+		&cli.Option{Name: "until", Store: &until, Default: "now", Help: "end of the query"},
+		&cli.Option{Name: "window", Store: &window, Help: "query window"},
+		&cli.Option{Name: "marks", Store: &marks, Help: "times to mark"},
+		&cli.Option{Name: "retention", Store: &retention, Help: "retention per index"},
+	)
+	assert.Contains(t, p.GenerateHelp(), "--since <time>")
+
+	_, err := p.Parse(nil, []string{
+		"--since", "yesterday",
+		"--window", "1w1d12h",
+		"--marks", "2018-06-01,2018-06-02T10:00:00Z,1528934400,-1.5d",
+		"--retention", "logs=30d,metrics=0.5w",
+	})
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2018, time.June, 14, 0, 0, 0, 0, time.UTC), since)
+	assert.Equal(t, now, until)
+	assert.Equal(t, 8*24*time.Hour+12*time.Hour, window)
+	require.Len(t, marks, 4)
+	assert.Equal(t, time.Date(2018, time.June, 1, 0, 0, 0, 0, time.UTC), marks[0])
+	assert.Equal(t, time.Date(2018, time.June, 2, 10, 0, 0, 0, time.UTC), marks[1])
+	assert.Equal(t, time.Date(2018, time.June, 14, 0, 0, 0, 0, time.UTC), marks[2])
+	assert.Equal(t, time.Date(2018, time.June, 14, 1, 30, 0, 0, time.UTC), marks[3])
+	assert.Equal(t, 30*24*time.Hour, retention["logs"])
+	assert.Equal(t, 84*time.Hour, retention["metrics"])
+
+	_, err = p.Parse(nil, []string{"--since", "-2h"})
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2018, time.June, 15, 11, 30, 0, 0, time.UTC), since)
+
+	_, err = p.Parse(nil, []string{"--since", "last tuesday"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'since': 'last tuesday' is not a valid time; expected "+
+		"'2006-01-02T15:04:05.999999999Z07:00', '2006-01-02T15:04:05', '2006-01-02 15:04:05', '2006-01-02', "+
+		"'unix' or a relative time such as '-2h' or 'yesterday'", err.Error())
+
+	_, err = p.Parse(nil, []string{"--window", "2 days"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'window': '2 days' is not a valid duration", err.Error())
+}
+
+func TestTimeLayouts(t *testing.T) {
+	var day time.Time
+
+	// Each parser uses its own layouts
+	p := cli.New(&cli.Config{TimeLayouts: []string{"02/01/2006"}})
+	p.Add(&cli.Option{Name: "day", Store: &day})
+
+	_, err := p.Parse(nil, []string{"--day", "15/06/2018"})
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2018, time.June, 15, 0, 0, 0, 0, time.Local), day)
+
+	_, err = p.Parse(nil, []string{"--day", "2018-06-15"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'day': '2018-06-15' is not a valid time; expected "+
+		"'02/01/2006' or a relative time such as '-2h' or 'yesterday'", err.Error())
+
+	other := cli.New(nil)
+	other.Add(&cli.Option{Name: "day", Store: &day})
+	_, err = other.Parse(nil, []string{"--day", "2018-06-16"})
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2018, time.June, 16, 0, 0, 0, 0, time.Local), day)
+}
+
+func TestSizedNumbers(t *testing.T) {
+	var level int8
+	var port uint16
+	var offsets []int32
+	var weights map[string]float32
+	var mode os.FileMode
+	var key, salt, raw []byte
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "level", Store: &level},
+		&cli.Option{Name: "port", Store: &port},
+		&cli.Option{Name: "offsets", Store: &offsets},
+		&cli.Option{Name: "weights", Store: &weights},
+		&cli.Option{Name: "mode", Store: &mode, Default: "0644", Help: "file permissions"},
+		&cli.Option{Name: "key", Store: &key, Flags: cli.Hex, Help: "encryption key"},
+		&cli.Option{Name: "salt", Store: &salt, Flags: cli.Base64, Help: "password salt"},
+		&cli.Option{Name: "raw", Store: &raw, Help: "raw bytes"},
+	)
+
+	help := p.GenerateHelp()
+	assert.Contains(t, help, "--mode <mode>")
+	assert.Contains(t, help, "--key <hex>")
+	assert.Contains(t, help, "--salt <base64>")
+	assert.Contains(t, help, "--raw <string>")
+
+	_, err := p.Parse(nil, []string{
+		"--level", "-0x10",
+		"--port", "0o17",
+		"--offsets", "0b101,-2147483648,1_000",
+		"--weights", "a=0.5,b=1e3",
+		"--key", "deadBEEF",
+		"--salt", "c2FsdA",
+		"--raw", "a,b",
+	})
+	require.Nil(t, err)
+	assert.Equal(t, int8(-16), level)
+	assert.Equal(t, uint16(15), port)
+	assert.Equal(t, []int32{5, -2147483648, 1000}, offsets)
+	assert.Equal(t, map[string]float32{"a": 0.5, "b": 1000}, weights)
+	assert.Equal(t, os.FileMode(0644), mode)
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, key)
+	assert.Equal(t, []byte("salt"), salt)
+	assert.Equal(t, []byte("a,b"), raw)
+
+	_, err = p.Parse(nil, []string{"--mode", "4755"})
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0755)|os.ModeSetuid, mode)
+
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{
+			args: []string{"--level", "128"},
+			err:  "invalid value for option 'level': '128' is out of range for int8",
+		},
+		{
+			args: []string{"--port", "-1"},
+			err:  "invalid value for option 'port': '-1' is not an unsigned integer",
+		},
+		{
+			args: []string{"--offsets", "1,2147483648"},
+			err:  "invalid value for option 'offsets': '2147483648' is out of range for int32",
+		},
+		{
+			args: []string{"--weights", "a=1e39"},
+			err:  "invalid value for option 'weights': '1e39' is out of range for float32",
+		},
+		{
+			args: []string{"--mode", "0999"},
+			err: "invalid value for option 'mode': '0999' is not a valid file mode; " +
+				"expected octal permissions such as '0644'",
+		},
+		{
+			args: []string{"--key", "xyz"},
+			err:  "invalid value for option 'key': 'xyz' is not valid hex",
+		},
+		{
+			args: []string{"--salt", "%%%"},
+			err:  "invalid value for option 'salt': '%%%' is not valid base64",
+		},
+	} {
+		_, err := p.Parse(nil, test.args)
+		require.NotNil(t, err)
+		assert.Equal(t, test.err, err.Error())
+	}
+}
+
+type Point struct {
+	X, Y int
+}
+
+func parsePoint(value string) (interface{}, error) {
+	var p Point
+	if _, err := fmt.Sscanf(value, "%d:%d", &p.X, &p.Y); err != nil {
+		return nil, fmt.Errorf("'%s' is not a point; expected 'x:y'", value)
+	}
+	return p, nil
+}
+
+func TestCastFunc(t *testing.T) {
+	var origin Point
+	var path []Point
+	var names map[string]string
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "origin", Store: &origin, Cast: parsePoint, Help: "the origin"},
+		&cli.Option{Name: "path", Store: &path, Cast: parsePoint, Help: "points on the path"},
+		&cli.Option{Name: "names", Store: &names, Cast: func(value string) (interface{}, error) {
+			return strings.ToUpper(value), nil
+		}},
+	)
+
+	help := p.GenerateHelp()
+	assert.Contains(t, help, "--origin <point>")
+	assert.Contains(t, help, "--path <point>,<point>")
+	assert.Contains(t, help, "--names <string>=<string>")
+
+	_, err := p.Parse(nil, []string{"--origin", "1:2", "--path", "3:4,5:6", "--names", "a=goku"})
+	require.Nil(t, err)
+	assert.Equal(t, Point{1, 2}, origin)
+	assert.Equal(t, []Point{{3, 4}, {5, 6}}, path)
+	assert.Equal(t, map[string]string{"a": "GOKU"}, names)
+
+	_, err = p.Parse(nil, []string{"--origin", "1,2"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'origin': '1,2' is not a point; expected 'x:y'", err.Error())
+
+	var count int
+	p = cli.New(nil)
+	p.Add(&cli.Option{Name: "count", Store: &count, Cast: parsePoint})
+	_, err = p.Parse(nil, []string{"--count", "1:2"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'count': CastFunc returned 'cli_test.Point'; expected 'int'", err.Error())
+}
+
+type Version struct {
+	Major, Minor int
+}
+
+func TestRegisterType(t *testing.T) {
+	cli.RegisterType(reflect.TypeOf(Version{}), func(value string) (interface{}, error) {
+		var v Version
+		if _, err := fmt.Sscanf(value, "v%d.%d", &v.Major, &v.Minor); err != nil {
+			return nil, fmt.Errorf("'%s' is not a version; expected 'vX.Y'", value)
+		}
+		return v, nil
+	}, "version")
+
+	var version Version
+	var supported []Version
+	var pinned map[string]Version
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "version", Store: &version},
+		&cli.Option{Name: "supported", Store: &supported},
+		&cli.Option{Name: "pinned", Store: &pinned},
+	)
+
+	help := p.GenerateHelp()
+	assert.Contains(t, help, "--version <version>")
+	assert.Contains(t, help, "--supported <version>,<version>")
+	assert.Contains(t, help, "--pinned <string>=<version>")
+
+	_, err := p.Parse(nil, []string{"--version", "v1.2", "--supported", "v1.0,v1.1", "--pinned", "api=v2.0"})
+	require.Nil(t, err)
+	assert.Equal(t, Version{1, 2}, version)
+	assert.Equal(t, []Version{{1, 0}, {1, 1}}, supported)
+	assert.Equal(t, map[string]Version{"api": {2, 0}}, pinned)
+
+	_, err = p.Parse(nil, []string{"--supported", "v1.0,latest"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'supported': 'latest' is not a version; expected 'vX.Y'", err.Error())
+
+	// Registered types are listed when an unsupported type is used
+	var unsupported []complex64
+	p = cli.New(nil)
+	p.Add(&cli.Option{Name: "foo", Store: &unsupported})
+	_, err = p.Parse(nil, []string{})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "cannot store '[]complex64'; only '")
+	assert.Contains(t, err.Error(), "[]cli_test.Version")
+	assert.NotContains(t, err.Error(), "map[string]")
+
+	var number complex64
+	p = cli.New(nil)
+	p.Add(&cli.Option{Name: "foo", Store: &number})
+	_, err = p.Parse(nil, []string{})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "cannot store 'complex64'; type not supported, only '")
+	assert.Contains(t, err.Error(), "cli_test.Version")
+}
+
+func TestOptionalStores(t *testing.T) {
+	var retries *int
+	var name *string
+	var timeout *time.Duration
+	var tags *[]string
+	var endpoint **url.URL
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "retries", Store: &retries, Validators: []cli.Validator{cli.Max(5)}},
+		&cli.Option{Name: "name", Store: &name},
+		&cli.Option{Name: "timeout", Store: &timeout, Default: "1m"},
+		&cli.Option{Name: "tags", Store: &tags},
+		&cli.Option{Name: "endpoint", Store: &endpoint},
+	)
+	assert.Contains(t, p.GenerateHelp(), "--retries <int>")
+	assert.Contains(t, p.GenerateHelp(), "--tags <string>,<string>")
+
+	// Values not provided are left nil
+	_, err := p.Parse(nil, []string{"--retries", "0"})
+	require.Nil(t, err)
+	require.NotNil(t, retries)
+	assert.Equal(t, 0, *retries)
+	assert.Nil(t, name)
+	assert.Nil(t, tags)
+	assert.Nil(t, endpoint)
+	require.NotNil(t, timeout)
+	assert.Equal(t, time.Minute, *timeout)
+
+	// Each value stored is a fresh pointer
+	first := retries
+	_, err = p.Parse(nil, []string{"--retries", "3", "--name", "", "--tags", "a,b", "--endpoint", "http://localhost"})
+	require.Nil(t, err)
+	assert.Equal(t, 0, *first)
+	assert.Equal(t, 3, *retries)
+	require.NotNil(t, name)
+	assert.Equal(t, "", *name)
+	assert.Equal(t, []string{"a", "b"}, *tags)
+	assert.Equal(t, "localhost", (*endpoint).Host)
+
+	_, err = p.Parse(nil, []string{"--retries", "6"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'retries' from 'cli-args': '6' must be at most 5", err.Error())
+
+	_, err = p.Parse(nil, []string{"--retries", "many"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'retries': 'many' is not an integer", err.Error())
+}
+
 type TestStruct struct {
 	StringOpt   string
 	IntOpt      int
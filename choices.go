@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// One of the values accepted by an option or argument. Choices are listed in help along
+// with their 'Help' message and offered by Complete().
+//
+//   type Level int
+//
+//   var level Level
+//   p.Add(&cli.Option{Name: "level", Store: &level, Default: "info", Flags: cli.IgnoreCase,
+//       Choices: []cli.Choice{
+//           {Name: "debug", Aliases: []string{"d"}, Help: "verbose output", Value: LevelDebug},
+//           {Name: "info", Help: "informational messages", Value: LevelInfo},
+//       }})
+type Choice struct {
+	// The value the user provides to select this choice
+	Name string
+	// Displayed next to the choice in help
+	Help string
+	// Alternative values which also select this choice
+	Aliases []string
+	// The value stored when this choice is selected. If nil the 'Name' is stored, which
+	// requires the 'Store' to be a string or custom string type such as `type Color string`
+	Value interface{}
+}
+
+// Returns the choice matching the value provided by name or alias
+func (r *rule) matchChoice(value string) (*Choice, bool) {
+	equal := func(a, b string) bool { return a == b }
+	if r.HasFlag(IgnoreCase) {
+		equal = strings.EqualFold
+	}
+
+	for i, choice := range r.Choices {
+		if equal(choice.Name, value) {
+			return &r.Choices[i], true
+		}
+		for _, alias := range choice.Aliases {
+			if equal(alias, value) {
+				return &r.Choices[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (r *rule) choiceNames() []string {
+	var results []string
+	for _, choice := range r.Choices {
+		results = append(results, choice.Name)
+	}
+	return results
+}
+
+func (r *rule) choiceError(value string) error {
+	return fmt.Errorf("'%s' is an invalid argument for '%s' choose from (%s)",
+		r.maskString(value, value), r.Name, strings.Join(r.choiceNames(), ", "))
+}
+
+// Returns an error if the value is not one of the rule's choices
+func (r *rule) validateChoices(value interface{}) error {
+	switch t := value.(type) {
+	case string:
+		if _, ok := r.matchChoice(t); !ok {
+			return r.choiceError(t)
+		}
+	case []string:
+		for _, item := range t {
+			if _, ok := r.matchChoice(item); !ok {
+				return r.choiceError(item)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the lines listing the rule's choices in help
+//
+//   debug, d   verbose output
+//   info       informational messages
+func (r *rule) generateChoicesHelp() []string {
+	var names []string
+	maxLen := 0
+	for _, choice := range r.Choices {
+		name := strings.Join(append([]string{choice.Name}, choice.Aliases...), ", ")
+		if len(name) > maxLen {
+			maxLen = len(name)
+		}
+		names = append(names, name)
+	}
+
+	var results []string
+	for i, choice := range r.Choices {
+		results = append(results, strings.TrimRight(fmt.Sprintf("%-*s   %s", maxLen, names[i], choice.Help), " "))
+	}
+	return results
+}
+
+// Creates the StoreFunc for a rule with choices, storing the 'Value' of the choice selected or the
+// choice 'Name' if no 'Value' was provided. Supports scalar and slice destinations.
+func newChoiceStoreFunc(r *rule, dest interface{}, d reflect.Value) error {
+	kind, elem := ScalarKind, d.Type()
+	if elem.Kind() == reflect.Slice {
+		kind, elem = SliceKind, elem.Elem()
+	}
+
+	switch elem.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return fmt.Errorf("cannot store '%s' with choices; only scalar and slice types are supported",
+			d.Type().String())
+	}
+
+	for _, choice := range r.Choices {
+		if choice.Value == nil {
+			if elem.Kind() != reflect.String {
+				return fmt.Errorf("choice '%s' must provide a 'Value' to store in '%s'", choice.Name, elem.String())
+			}
+			continue
+		}
+		t := reflect.TypeOf(choice.Value)
+		if t.Kind() != elem.Kind() || !t.ConvertibleTo(elem) {
+			return fmt.Errorf("cannot store the value of choice '%s' of type '%s' in '%s'",
+				choice.Name, t.String(), elem.String())
+		}
+	}
+
+	toValue := func(value string) (reflect.Value, error) {
+		choice, ok := r.matchChoice(strings.TrimSpace(value))
+		if !ok {
+			return reflect.Value{}, r.choiceError(value)
+		}
+		if choice.Value == nil {
+			return reflect.ValueOf(choice.Name).Convert(elem), nil
+		}
+		return reflect.ValueOf(choice.Value).Convert(elem), nil
+	}
+
+	ptr := d
+	r.StoreFuncs = append(r.StoreFuncs, func(value interface{}, count int) error {
+		if kind == ScalarKind {
+			v, err := toValue(value.(string))
+			if err != nil {
+				return err
+			}
+			ptr.Set(v)
+			return nil
+		}
+
+		items := value.([]string)
+		result := reflect.MakeSlice(ptr.Type(), 0, len(items))
+		for _, item := range items {
+			v, err := toValue(item)
+			if err != nil {
+				return err
+			}
+			result = reflect.Append(result, v)
+		}
+		ptr.Set(result)
+		return nil
+	})
+
+	r.SetFlag(kind, true)
+	r.Dests = append(r.Dests, dest)
+	r.Usage = "<" + strings.Join(r.choiceNames(), "|") + ">"
+	if kind == SliceKind {
+		r.Usage = fmt.Sprintf("%[1]s,%[1]s", r.Usage)
+	}
+	return nil
+}
+
+// Returns the completion candidates for the last word in 'argv', suitable for use by a shell
+// completion script. If the previous word is an option which has choices the matching choices
+// are returned, if the last word begins with '-' the matching option names are returned.
+//
+//   // Called by the completion script as `app --complete -- --level d`
+//   for _, word := range p.Complete([]string{"--level", "d"}) {
+//       fmt.Println(word)
+//   }
+func (p *Parser) Complete(argv []string) []string {
+	var word, prev string
+	if len(argv) != 0 {
+		word = argv[len(argv)-1]
+	}
+	if len(argv) > 1 {
+		prev = argv[len(argv)-2]
+	}
+
+	var results []string
+	if pos := hasFlagPrefix(prev); pos != 0 {
+		rule := p.rules.GetRuleByAlias(prev[pos:])
+		if rule != nil && rule.HasFlag(isExpectingValue) && len(rule.Choices) != 0 {
+			for _, choice := range rule.Choices {
+				if hasPrefix(choice.Name, word, rule.HasFlag(IgnoreCase)) {
+					results = append(results, choice.Name)
+				}
+			}
+			return results
+		}
+	}
+
+	if !strings.HasPrefix(word, "-") {
+		return nil
+	}
+
+	for _, rule := range p.rules {
+		if !rule.HasFlag(isOption) || rule.HasFlag(Hidden) {
+			continue
+		}
+		for _, alias := range rule.Aliases {
+			flag := "-" + alias
+			if len(alias) > 2 {
+				flag = "--" + alias
+			}
+			if strings.HasPrefix(flag, word) {
+				results = append(results, flag)
+			}
+		}
+	}
+	sort.Strings(results)
+	return results
+}
+
+func hasPrefix(s, prefix string, ignoreCase bool) bool {
+	if ignoreCase {
+		return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+	}
+	return strings.HasPrefix(s, prefix)
+}
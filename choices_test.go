@@ -0,0 +1,89 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/harbor-pkgs/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+)
+
+type Color string
+
+func TestChoices(t *testing.T) {
+	var level Level
+	var colors []Color
+	var format string
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "level", Store: &level, Default: "info", Flags: cli.IgnoreCase,
+			Help: "the log level", Choices: []cli.Choice{
+				{Name: "debug", Aliases: []string{"d"}, Help: "verbose output", Value: LevelDebug},
+				{Name: "info", Help: "informational messages", Value: LevelInfo},
+				{Name: "warn", Aliases: []string{"w", "warning"}, Help: "only warnings", Value: LevelWarn},
+			}},
+		&cli.Option{Name: "colors", Store: &colors, Help: "colors to use", Choices: []cli.Choice{
+			{Name: "red"}, {Name: "green"}, {Name: "blue"},
+		}},
+		&cli.Argument{Name: "format", Store: &format, Choices: []cli.Choice{
+			{Name: "json", Help: "machine readable"},
+			{Name: "text", Help: "human readable"},
+		}},
+	)
+
+	help := p.GenerateHelp()
+	assert.Contains(t, help, ""+
+		"  --level <debug|info|warn>                    the log level (default=info)\n"+
+		"                                                 debug, d           verbose output\n"+
+		"                                                 info               informational messages\n"+
+		"                                                 warn, w, warning   only warnings\n")
+	assert.Contains(t, help, ""+
+		"  --colors <red|green|blue>,<red|green|blue>   colors to use\n"+
+		"                                                 red\n")
+	assert.Contains(t, help, ""+
+		"  format   \n"+
+		"             json   machine readable\n"+
+		"             text   human readable\n")
+
+	_, err := p.Parse(nil, []string{"--colors", "red,blue"})
+	require.Nil(t, err)
+	assert.Equal(t, LevelInfo, level)
+	assert.Equal(t, []Color{"red", "blue"}, colors)
+
+	_, err = p.Parse(nil, []string{"--level", "WARNING"})
+	require.Nil(t, err)
+	assert.Equal(t, LevelWarn, level)
+
+	_, err = p.Parse(nil, []string{"--level", "D"})
+	require.Nil(t, err)
+	assert.Equal(t, LevelDebug, level)
+
+	_, err = p.Parse(nil, []string{"--colors", "red,Blue"})
+	require.NotNil(t, err)
+	assert.Equal(t, "'Blue' is an invalid argument for 'colors' choose from (red, green, blue)", err.Error())
+
+	assert.Equal(t, []string{"debug"}, p.Complete([]string{"--level", "D"}))
+	assert.Equal(t, []string{"red"}, p.Complete([]string{"--colors", "r"}))
+	assert.Equal(t, []string{"--colors"}, p.Complete([]string{"--c"}))
+	assert.Nil(t, p.Complete([]string{"js"}))
+}
+
+func TestChoicesInvalidStore(t *testing.T) {
+	var level Level
+	p := cli.New(nil)
+	p.Add(&cli.Option{Name: "level", Store: &level, Choices: []cli.Choice{{Name: "debug"}}})
+
+	_, err := p.Parse(nil, []string{})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid 'Store' while adding option 'level': "+
+		"choice 'debug' must provide a 'Value' to store in 'cli_test.Level'")
+}
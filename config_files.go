@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The name of the option added by `LoadConfigFiles`
+const configOptionName = "config"
+
+// The file names searched for within each config directory, in the order they are loaded
+var configFileNames = []string{"config.ini", "config.toml", "config.json"}
+
+// A store which layers the config files discovered by `LoadConfigFiles`,
+// values from files loaded later override values from files loaded earlier
+type configStore struct {
+	stores []FromStore
+}
+
+// Returns a store that represents the config files discovered by `LoadConfigFiles` for use with SetPrecedence()
+func ConfigStore() FromStore {
+	return &configStore{}
+}
+
+func (c *configStore) bind(ctx context.Context, p *Parser) error {
+	c.stores = nil
+	p.configFiles = nil
+	if !p.HasMode(LoadConfigFiles) {
+		return nil
+	}
+
+	for _, file := range p.configSearchPaths() {
+		store, err := openConfigFile(file)
+		if err != nil {
+			// Files in the search path are optional
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		c.stores = append(c.stores, store)
+		p.configFiles = append(p.configFiles, file)
+	}
+
+	// The file provided via --config must exist and is loaded last
+	file, err := p.peekValue(ctx, configOptionName)
+	if err != nil {
+		return err
+	}
+	if file != "" {
+		store, err := openConfigFile(file)
+		if err != nil {
+			return err
+		}
+		c.stores = append(c.stores, store)
+		p.configFiles = append(p.configFiles, file)
+	}
+	return nil
+}
+
+func (c *configStore) Source() string {
+	return configSource
+}
+
+func (c *configStore) Get(ctx context.Context, name string, flags Flags) (interface{}, int, error) {
+	for i := len(c.stores) - 1; i >= 0; i-- {
+		value, count, err := c.stores[i].Get(ctx, name, flags)
+		if err != nil {
+			return nil, 0, err
+		}
+		if count != 0 {
+			return value, count, nil
+		}
+	}
+	return nil, 0, nil
+}
+
+func (c *configStore) Origin(ctx context.Context, name string) (string, string) {
+	for i := len(c.stores) - 1; i >= 0; i-- {
+		o, ok := c.stores[i].(OriginStore)
+		if !ok {
+			continue
+		}
+		if raw, origin := o.Origin(ctx, name); origin != "" {
+			return raw, origin
+		}
+	}
+	return "", ""
+}
+
+func (c *configStore) Keys(ctx context.Context) []string {
+	var results []string
+	for _, store := range c.stores {
+		lister, ok := store.(KeyLister)
+		if !ok {
+			continue
+		}
+		for _, key := range lister.Keys(ctx) {
+			if !ContainsString(key, results, nil) {
+				results = append(results, key)
+			}
+		}
+	}
+	return results
+}
+
+// Opens the config file and returns a store chosen by the file extension; files
+// ending in '.json' or '.toml' are parsed accordingly, all other files are parsed as INI
+func openConfigFile(file string) (FromStore, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var newStore func(io.Reader) (FromStore, error)
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		newStore = NewJSONStore
+	case ".toml":
+		newStore = NewTOMLStore
+	default:
+		newStore = NewIniStore
+	}
+
+	store, err := newStore(fd)
+	if err != nil {
+		return nil, fmt.Errorf("while loading config file '%s': %s", file, err)
+	}
+	return store, nil
+}
+
+// Returns the config files searched by `LoadConfigFiles` in the order they are
+// loaded. Unless overridden by `Config.ConfigPaths` the search path is
+//
+//   /etc/<name>/config.{ini,toml,json}
+//   $XDG_CONFIG_HOME/<name>/config.{ini,toml,json}
+//   ./.<name>rc
+func (p *Parser) configSearchPaths() []string {
+	if p.cfg.ConfigPaths != nil {
+		return p.cfg.ConfigPaths
+	}
+
+	dirs := []string{filepath.Join("/etc", p.cfg.Name)}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		dirs = append(dirs, filepath.Join(configHome, p.cfg.Name))
+	}
+
+	var results []string
+	for _, dir := range dirs {
+		for _, name := range configFileNames {
+			results = append(results, filepath.Join(dir, name))
+		}
+	}
+	return append(results, "."+p.cfg.Name+"rc")
+}
+
+// Returns the name of the env var which provides the value for --config IE: 'MY_APP_CONFIG'
+func (p *Parser) configEnvVar() string {
+	return envNameReplacer.Replace(strings.ToUpper(p.cfg.Name)) + "_CONFIG"
+}
+
+func fileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
@@ -0,0 +1,136 @@
+package cli_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/harbor-pkgs/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, file, contents string) string {
+	require.Nil(t, ioutil.WriteFile(file, []byte(contents), 0644))
+	return file
+}
+
+func TestLoadConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	system := writeFile(t, filepath.Join(dir, "system.ini"), "name=system\ncolor=red\nsize=small\n")
+	user := writeFile(t, filepath.Join(dir, "user.toml"), "color = \"blue\"\n")
+	explicit := writeFile(t, filepath.Join(dir, "explicit.json"), `{"size": "large"}`)
+
+	newParser := func() (*cli.Parser, *string, *string, *string) {
+		var name, color, size string
+		p := cli.New(&cli.Config{
+			Name:        "demo",
+			Mode:        cli.LoadConfigFiles,
+			ConfigPaths: []string{system, filepath.Join(dir, "missing.ini"), user},
+		})
+		p.Add(
+			&cli.Option{Name: "name", Store: &name},
+			&cli.Option{Name: "color", Store: &color},
+			&cli.Option{Name: "size", Store: &size},
+		)
+		return p, &name, &color, &size
+	}
+
+	// Later files override earlier files
+	p, name, color, size := newParser()
+	_, err := p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, "system", *name)
+	assert.Equal(t, "blue", *color)
+	assert.Equal(t, "small", *size)
+
+	src, ok := p.Source("color")
+	require.True(t, ok)
+	assert.Equal(t, "cli-config", src.Source)
+	assert.Equal(t, user+":1:1", src.Origin)
+
+	// The file provided via --config is loaded last, the command line still wins
+	p, name, color, size = newParser()
+	_, err = p.Parse(nil, []string{"-c", explicit, "--name", "argv"})
+	require.Nil(t, err)
+	assert.Equal(t, "argv", *name)
+	assert.Equal(t, "blue", *color)
+	assert.Equal(t, "large", *size)
+
+	// The config file can be provided via the environment
+	os.Setenv("DEMO_CONFIG", explicit)
+	defer os.Unsetenv("DEMO_CONFIG")
+
+	p, _, _, size = newParser()
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, "large", *size)
+}
+
+func TestLoadConfigFilesErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := writeFile(t, filepath.Join(dir, "bad.ini"), "[global\n")
+
+	var name string
+	p := cli.New(&cli.Config{Name: "demo", Mode: cli.LoadConfigFiles, ConfigPaths: []string{}})
+	p.Add(&cli.Option{Name: "name", Store: &name})
+
+	// An explicit config file must exist
+	_, err := p.Parse(nil, []string{"--config", filepath.Join(dir, "missing.ini")})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "missing.ini")
+
+	_, err = p.Parse(nil, []string{"--config", bad})
+	require.NotNil(t, err)
+	assert.Equal(t, "while loading config file '"+bad+"': INI parse error at "+bad+
+		":1: expected ']' to close section header", err.Error())
+}
+
+func TestLoadConfigFilesHelp(t *testing.T) {
+	dir := t.TempDir()
+	found := writeFile(t, filepath.Join(dir, "found.ini"), "name=found\n")
+	missing := filepath.Join(dir, "missing.ini")
+
+	var name string
+	p := cli.New(&cli.Config{Name: "demo", Mode: cli.LoadConfigFiles, ConfigPaths: []string{missing, found}})
+	p.Add(&cli.Option{Name: "name", Store: &name})
+
+	_, err := p.Parse(nil, []string{"-h"})
+	require.True(t, cli.IsHelpError(err))
+
+	help := p.GenerateHelp()
+	assert.Contains(t, help, "--config, -c <string>")
+	assert.Contains(t, help, "env=DEMO_CONFIG")
+
+	idx := strings.Index(help, "Config Files:\n")
+	require.NotEqual(t, -1, idx)
+	assert.Equal(t, "Config Files:\n  "+missing+"\n  "+found+" (found)\n", help[idx:])
+
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Contains(t, p.GenerateHelp(), "  "+found+" (loaded)\n")
+}
+
+func TestLoadConfigFilesAliasTaken(t *testing.T) {
+	explicit := writeFile(t, filepath.Join(t.TempDir(), "explicit.ini"), "name=explicit\n")
+
+	var name string
+	var count int
+	p := cli.New(&cli.Config{Name: "demo", Mode: cli.LoadConfigFiles, ConfigPaths: []string{}})
+	p.Add(
+		&cli.Option{Name: "name", Store: &name},
+		&cli.Option{Name: "count", Store: &count, Aliases: []string{"c"}},
+	)
+
+	// The user's '-c' option wins, the config file is still available via '--config'
+	_, err := p.Parse(nil, []string{"-c", "3", "--config", explicit})
+	require.Nil(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, "explicit", name)
+
+	help := p.GenerateHelp()
+	assert.Contains(t, help, "--config <string>")
+	assert.NotContains(t, help, "--config, -c")
+}
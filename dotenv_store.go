@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+type dotEnvValue struct {
+	Value string
+	Line  int
+}
+
+// Returns a store which provides values from a dotenv (.env) file. Like the environment, values
+// are matched to rules by the rule's environment variable name rather than the rule name.
+//
+//   # Comments and blank lines are ignored
+//   export POWER_LEVEL=9000
+//   NAME="Goku ${POWER_LEVEL}"
+//   MOTD="Multi-line values
+//   are quoted"
+//   PATTERN='single quoted values are ${NOT} interpolated'
+//
+// Double quoted values support the escape sequences '\n', '\t', '\r', '\"', '\\' and '\$'.
+// Double quoted and un-quoted values interpolate '${VAR}' from variables defined earlier in
+// the file, falling back to the process environment.
+func NewDotEnvStore(r io.Reader) (FromStore, error) {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	name := readerName(r)
+	values, err := parseDotEnv(string(contents), name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &envStore{
+		source: "dotenv-store",
+		lookup: func(key string) (string, string, bool) {
+			v, ok := values[key]
+			if !ok {
+				return "", "", false
+			}
+			return v.Value, fileOrigin(name, []int{v.Line}), true
+		},
+		names: func() []string {
+			var results []string
+			for key := range values {
+				results = append(results, key)
+			}
+			return results
+		},
+	}, nil
+}
+
+func parseDotEnv(contents, name string) (map[string]dotEnvValue, error) {
+	values := make(map[string]dotEnvValue)
+	fail := func(line int, format string, args ...interface{}) error {
+		return fmt.Errorf("dotenv parse error at %s: %s", fileOrigin(name, []int{line}), fmt.Sprintf(format, args...))
+	}
+
+	lines := strings.Split(strings.Replace(contents, "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := strings.TrimSpace(lines[i])
+
+		// Skip empty lines and comments
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "export\t") {
+			line = strings.TrimSpace(line[len("export"):])
+		}
+
+		idx := strings.IndexByte(line, '=')
+		if idx == -1 {
+			return nil, fail(lineNum, "expected '=' after key '%s'", line)
+		}
+
+		key := strings.TrimSpace(line[:idx])
+		if !isDotEnvKey(key) {
+			return nil, fail(lineNum, "invalid key '%s'", key)
+		}
+
+		lookup := func(name string) string {
+			if v, ok := values[name]; ok {
+				return v.Value
+			}
+			return os.Getenv(name)
+		}
+
+		raw := strings.TrimLeft(line[idx+1:], " \t")
+		if raw == "" || (raw[0] != '"' && raw[0] != '\'') {
+			// Un-quoted values end at an inline comment
+			if idx := strings.Index(raw, " #"); idx != -1 {
+				raw = raw[:idx]
+			}
+			value, err := expandDotEnv(strings.TrimSpace(raw), false, lookup)
+			if err != nil {
+				return nil, fail(lineNum, "%s", err)
+			}
+			values[key] = dotEnvValue{Value: value, Line: lineNum}
+			continue
+		}
+
+		// Quoted values may span multiple lines
+		quote := raw[0]
+		raw = raw[1:]
+		end := findDotEnvQuote(raw, quote)
+		for end == -1 && i+1 < len(lines) {
+			i++
+			raw += "\n" + lines[i]
+			end = findDotEnvQuote(raw, quote)
+		}
+		if end == -1 {
+			return nil, fail(lineNum, "unterminated quote; expected closing %c", quote)
+		}
+
+		trailing := strings.TrimSpace(raw[end+1:])
+		if trailing != "" && trailing[0] != '#' {
+			return nil, fail(lineNum, "unexpected '%s' after value for key '%s'", trailing, key)
+		}
+
+		value := raw[:end]
+		if quote == '"' {
+			var err error
+			if value, err = expandDotEnv(value, true, lookup); err != nil {
+				return nil, fail(lineNum, "%s", err)
+			}
+		}
+		values[key] = dotEnvValue{Value: value, Line: lineNum}
+	}
+	return values, nil
+}
+
+// Returns the index of the closing quote or -1 if not found. Quotes escaped with '\'
+// are skipped within double quoted values
+func findDotEnvQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// Interpolates '${VAR}' references and un-escapes escape sequences if 'escapes' is true
+func expandDotEnv(s string, escapes bool, lookup func(string) string) (string, error) {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && escapes && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case 'r':
+				buf.WriteByte('\r')
+			case '"', '\\', '$':
+				buf.WriteByte(s[i])
+			default:
+				buf.WriteByte('\\')
+				buf.WriteByte(s[i])
+			}
+		case c == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end == -1 {
+				return "", fmt.Errorf("expected '}' to close '%s'", s[i:])
+			}
+			name := s[i+2 : i+end]
+			if !isDotEnvKey(name) {
+				return "", fmt.Errorf("invalid variable name '%s' in '%s'", name, s[i:i+end+1])
+			}
+			buf.WriteString(lookup(name))
+			i += end
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String(), nil
+}
+
+func isDotEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		switch {
+		case c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case c >= '0' && c <= '9' && i != 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
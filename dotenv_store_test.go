@@ -0,0 +1,113 @@
+package cli_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/harbor-pkgs/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dotEnvFile = `
+# A comment
+export POWER_LEVEL=9000
+NAME="Goku ${POWER_LEVEL}" # inline comment
+MOTD="first line
+second \"line\"\tend"
+PATTERN='literal ${POWER_LEVEL} \n'
+HOME_DIR=${DOTENV_TEST_HOME}/goku
+TAGS=a,b,c
+`
+
+func TestDotEnvStore(t *testing.T) {
+	os.Setenv("DOTENV_TEST_HOME", "/home")
+	defer os.Unsetenv("DOTENV_TEST_HOME")
+
+	store, err := cli.NewDotEnvStore(bytes.NewBufferString(dotEnvFile))
+	require.Nil(t, err)
+
+	var name, motd, pattern, home string
+	var tags []string
+	var power int
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "power-level", Env: "POWER_LEVEL", Store: &power},
+		&cli.Option{Name: "name", Env: "NAME", Store: &name},
+		&cli.Option{Name: "motd", Env: "MOTD", Store: &motd},
+		&cli.Option{Name: "pattern", Env: "PATTERN", Store: &pattern},
+		&cli.Option{Name: "home", Env: "HOME_DIR", Store: &home},
+		&cli.Option{Name: "tags", Env: "TAGS", Store: &tags},
+	)
+	p.AddStore(store)
+
+	_, err = p.Parse(nil, []string{"--name", "Vegeta"})
+	require.Nil(t, err)
+
+	assert.Equal(t, 9000, power)
+	assert.Equal(t, "Vegeta", name)
+	assert.Equal(t, "first line\nsecond \"line\"\tend", motd)
+	assert.Equal(t, `literal ${POWER_LEVEL} \n`, pattern)
+	assert.Equal(t, "/home/goku", home)
+	assert.Equal(t, []string{"a", "b", "c"}, tags)
+
+	src, ok := p.Source("motd")
+	require.True(t, ok)
+	assert.Equal(t, "dotenv-store", src.Source)
+	assert.Equal(t, "line 5", src.Origin)
+
+	// Values are matched by env var name, not by rule name
+	store, err = cli.NewDotEnvStore(bytes.NewBufferString(dotEnvFile))
+	require.Nil(t, err)
+
+	p = cli.New(nil)
+	p.Add(&cli.Option{Name: "NAME", Store: &name})
+	p.AddStore(store)
+
+	name = ""
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, "", name)
+}
+
+func TestDotEnvStoreErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		file string
+		err  string
+	}{
+		{
+			name: "missing equals",
+			file: "FOO",
+			err:  "dotenv parse error at line 1: expected '=' after key 'FOO'",
+		},
+		{
+			name: "invalid key",
+			file: "\n1FOO=bar",
+			err:  "dotenv parse error at line 2: invalid key '1FOO'",
+		},
+		{
+			name: "unterminated quote",
+			file: "FOO=\"bar\nBAR=foo",
+			err:  "dotenv parse error at line 1: unterminated quote; expected closing \"",
+		},
+		{
+			name: "trailing characters",
+			file: "FOO='bar' baz",
+			err:  "dotenv parse error at line 1: unexpected 'baz' after value for key 'FOO'",
+		},
+		{
+			name: "unterminated interpolation",
+			file: "FOO=${BAR",
+			err:  "dotenv parse error at line 1: expected '}' to close '${BAR'",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := cli.NewDotEnvStore(bytes.NewBufferString(test.file))
+			require.NotNil(t, err)
+			assert.Equal(t, test.err, err.Error())
+		})
+	}
+}
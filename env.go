@@ -2,21 +2,62 @@ package cli
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+// Replaces characters which are not valid in environment variable names
+var envNameReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// A store which provides values for rules with an environment variable defined. In addition to
+// a comma separated value, slices can be provided via indexed variables 'TAGS_0', 'TAGS_1' and
+// maps with the `EnvKeys` flag via keyed variables 'LABELS_env=prod', 'LABELS_team=core' which
+// are merged into the value.
 type envStore struct {
-	rules ruleList
+	rules  ruleList
+	source string
+	// Returns the value of the variable and where the value was found
+	lookup func(string) (string, string, bool)
+	// Returns the names of all the variables available
+	names func() []string
+}
+
+// An indexed 'NAME_0' or keyed 'NAME_key' variable which provides an item of a slice or map value
+type envItem struct {
+	Key  string
+	Name string
+}
+
+// Returns a store that represents the environment for use with SetPrecedence()
+func EnvStore() FromStore {
+	return &envStore{source: envSource, lookup: lookupEnv, names: environNames}
+}
+
+func lookupEnv(name string) (string, string, bool) {
+	value, ok := os.LookupEnv(name)
+	return value, name, ok
 }
 
-func newEnvStore(rules ruleList) *envStore {
-	return &envStore{
-		rules: rules,
+func environNames() []string {
+	var results []string
+	for _, env := range os.Environ() {
+		if idx := strings.IndexByte(env, '='); idx > 0 {
+			results = append(results, env[:idx])
+		}
 	}
+	return results
+}
+
+func (e *envStore) bind(ctx context.Context, p *Parser) error {
+	e.rules = p.rules
+	return nil
 }
 
 func (e *envStore) Source() string {
-	return envSource
+	return e.source
 }
 
 func (e *envStore) Get(ctx context.Context, name string, flags Flags) (interface{}, int, error) {
@@ -29,10 +70,186 @@ func (e *envStore) Get(ctx context.Context, name string, flags Flags) (interface
 		return nil, 0, nil
 	}
 
-	value := os.Getenv(rule.EnvVar)
-	if value == "" {
-		return nil, 0, nil
+	value, _, err := e.value(rule)
+	if err != nil {
+		return nil, 0, err
+	}
+	items, err := e.items(rule.EnvVar, flags)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(items) == 0 {
+		if value == "" {
+			return nil, 0, nil
+		}
+		return convToKind([]string{value}, flags, 1)
+	}
+
+	// Merge the indexed or keyed variables with the value of the variable
+	var base interface{}
+	if value != "" {
+		var err error
+		if base, _, err = convToKind([]string{value}, flags, 1); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if flags.Has(SliceKind) {
+		results, _ := base.([]string)
+		for _, item := range items {
+			v, _, _ := e.lookup(item.Name)
+			results = append(results, v)
+		}
+		return results, 1, nil
 	}
 
-	return convToKind([]string{value}, flags, 1)
+	results, _ := base.(map[string]string)
+	if results == nil {
+		results = make(map[string]string)
+	}
+	for _, item := range items {
+		results[item.Key], _, _ = e.lookup(item.Name)
+	}
+	return results, 1, nil
+}
+
+// Returns the value of the rule's variable and where it was found. If the rule has `FromFile`
+// set and the variable is empty, the value is read from the file named by '<ENV>_FILE'
+func (e *envStore) value(rule *rule) (string, string, error) {
+	value, origin, _ := e.lookup(rule.EnvVar)
+	if value != "" || !rule.HasFlag(FromFile) {
+		return value, origin, nil
+	}
+
+	file, _, _ := e.lookup(rule.EnvVar + "_FILE")
+	if file == "" {
+		return "", origin, nil
+	}
+
+	contents, err := readValueFile(file)
+	if err != nil {
+		return "", "", fmt.Errorf("while reading '%s_FILE': %s", rule.EnvVar, err)
+	}
+	return contents, file, nil
+}
+
+// Returns the indexed variables 'NAME_0', 'NAME_1' for slices or the keyed variables
+// 'NAME_key' for maps with the `EnvKeys` flag in the order they should be applied
+func (e *envStore) items(prefix string, flags Flags) ([]envItem, error) {
+	if e.names == nil || !(flags.Has(SliceKind) || flags.Has(MapKind) && flags.Has(EnvKeys)) {
+		return nil, nil
+	}
+
+	var results []envItem
+	indexes := make(map[uint64]string)
+	for _, name := range e.names() {
+		if !strings.HasPrefix(name, prefix+"_") || len(name) == len(prefix)+1 {
+			continue
+		}
+		key := name[len(prefix)+1:]
+		// '<ENV>_FILE' names the file to read the value from
+		if key == "FILE" && flags.Has(FromFile) {
+			continue
+		}
+		if flags.Has(SliceKind) {
+			idx, err := strconv.ParseUint(key, 10, 32)
+			if err != nil {
+				continue
+			}
+			// 'TAGS_01' and 'TAGS_1' provide the same index
+			if other, ok := indexes[idx]; ok {
+				if other > name {
+					other, name = name, other
+				}
+				return nil, fmt.Errorf("environment variables '%s' and '%s' provide the same index", other, name)
+			}
+			indexes[idx] = name
+		}
+		results = append(results, envItem{Key: key, Name: name})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if flags.Has(SliceKind) {
+			a, _ := strconv.Atoi(results[i].Key)
+			b, _ := strconv.Atoi(results[j].Key)
+			return a < b
+		}
+		return results[i].Key < results[j].Key
+	})
+	return results, nil
+}
+
+func (e *envStore) Origin(ctx context.Context, name string) (string, string) {
+	rule := e.rules.GetRule(name)
+	if rule == nil || rule.EnvVar == "" {
+		return "", ""
+	}
+	value, origin, _ := e.value(rule)
+	items, _ := e.items(rule.EnvVar, rule.Flags)
+	if len(items) == 0 {
+		return value, origin
+	}
+
+	var values, origins []string
+	if value != "" {
+		values = append(values, value)
+		origins = append(origins, origin)
+	}
+	for _, item := range items {
+		v, o, _ := e.lookup(item.Name)
+		if rule.HasFlag(MapKind) {
+			v = item.Key + "=" + v
+		}
+		values = append(values, v)
+		origins = append(origins, o)
+	}
+	return strings.Join(values, ","), strings.Join(origins, ", ")
+}
+
+// Assigns the derived environment variable to each option without one if `AutoEnvVars` is set.
+// Names derived by a previous call are derived again such that changes to the config apply.
+func (p *Parser) deriveEnvVars() {
+	for _, rule := range p.rules {
+		if rule.HasFlag(isDerivedEnv) {
+			rule.EnvVar = ""
+			rule.SetFlag(isDerivedEnv, false)
+		}
+		if name := p.derivedEnvVar(rule); name != "" {
+			rule.EnvVar = name
+			rule.SetFlag(isDerivedEnv, true)
+		}
+	}
+}
+
+// Returns the environment variable name derived for the rule, or an empty string
+// if `AutoEnvVars` is not set or the rule does not need a derived name
+func (p *Parser) derivedEnvVar(r *rule) string {
+	if !p.HasMode(AutoEnvVars) || !r.HasFlag(isOption) || r.HasFlag(isHelpRule) || r.EnvVar != "" {
+		return ""
+	}
+	return p.envVarName(r.Name)
+}
+
+// Returns the rules with derived environment variable names for display in help and generated
+// configs. Rules which need a derived name are copied such that the parser is not modified.
+func (p *Parser) rulesWithEnvVars() ruleList {
+	results := make(ruleList, 0, len(p.rules))
+	for _, r := range p.rules {
+		if name := p.derivedEnvVar(r); name != "" {
+			c := *r
+			c.EnvVar = name
+			r = &c
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// Returns the environment variable name derived from the prefix, sub commands and the name provided
+func (p *Parser) envVarName(name string) string {
+	parts := append(p.commandPath(), name)
+	if p.cfg.EnvPrefix != "" {
+		parts = append([]string{strings.TrimSuffix(p.cfg.EnvPrefix, "_")}, parts...)
+	}
+	return envNameReplacer.Replace(strings.ToUpper(strings.Join(parts, "_")))
 }
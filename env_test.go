@@ -0,0 +1,242 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harbor-pkgs/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoEnvVars(t *testing.T) {
+	var power int
+	var name, endpoint string
+
+	p := cli.New(&cli.Config{EnvPrefix: "DEMO", Mode: cli.AutoEnvVars})
+	p.Add(
+		&cli.Option{Name: "power-level", Store: &power, Help: "the power level"},
+		&cli.Option{Name: "server.endpoint", Store: &endpoint},
+		&cli.Option{Name: "name", Env: "NAME", Store: &name},
+	)
+
+	os.Setenv("DEMO_POWER_LEVEL", "9000")
+	os.Setenv("DEMO_SERVER_ENDPOINT", "localhost:8080")
+	os.Setenv("NAME", "Goku")
+	defer func() {
+		os.Unsetenv("DEMO_POWER_LEVEL")
+		os.Unsetenv("DEMO_SERVER_ENDPOINT")
+		os.Unsetenv("NAME")
+	}()
+
+	// Derived names are included in help and the generated env config
+	assert.Contains(t, p.GenerateHelp(), "the power level (env=DEMO_POWER_LEVEL)")
+	assert.Contains(t, string(p.GenerateEnvConfig()), "# export DEMO_POWER_LEVEL=<int>")
+
+	_, err := p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, 9000, power)
+	assert.Equal(t, "localhost:8080", endpoint)
+	// Explicit names are not prefixed
+	assert.Equal(t, "Goku", name)
+
+	src, ok := p.Source("power-level")
+	require.True(t, ok)
+	assert.Equal(t, "DEMO_POWER_LEVEL", src.Origin)
+}
+
+func TestAutoEnvVarsSubCommand(t *testing.T) {
+	var verbose bool
+	var image string
+
+	p := cli.New(&cli.Config{EnvPrefix: "DEMO", Mode: cli.AutoEnvVars})
+	p.Add(
+		&cli.Option{Name: "verbose", Store: &verbose},
+		&cli.Command{Name: "run", Func: func(ctx context.Context, sub *cli.Parser) (int, error) {
+			sub.Add(&cli.Option{Name: "image", Store: &image, Help: "the image to run"})
+			assert.Contains(t, sub.GenerateHelp(), "the image to run (env=DEMO_RUN_IMAGE)")
+			return sub.Parse(ctx, nil)
+		}},
+	)
+
+	// Generating help does not assign the derived names to the rules
+	p.GenerateHelp()
+	p.SetMode(cli.AutoEnvVars, false)
+	assert.NotContains(t, p.GenerateHelp(), "DEMO_VERBOSE")
+	p.SetMode(cli.AutoEnvVars, true)
+
+	os.Setenv("DEMO_VERBOSE", "true")
+	os.Setenv("DEMO_RUN_IMAGE", "alpine")
+	defer func() {
+		os.Unsetenv("DEMO_VERBOSE")
+		os.Unsetenv("DEMO_RUN_IMAGE")
+	}()
+
+	_, err := p.Parse(nil, []string{"run"})
+	require.Nil(t, err)
+	// Options of the parent are not prefixed with the sub command
+	assert.Equal(t, true, verbose)
+	assert.Equal(t, "alpine", image)
+}
+
+func TestAutoEnvVarsDisabled(t *testing.T) {
+	var power int
+
+	p := cli.New(&cli.Config{EnvPrefix: "DEMO"})
+	p.Add(&cli.Option{Name: "power-level", Store: &power})
+
+	os.Setenv("DEMO_POWER_LEVEL", "9000")
+	defer os.Unsetenv("DEMO_POWER_LEVEL")
+
+	_, err := p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, 0, power)
+}
+
+func TestStructuredEnvVars(t *testing.T) {
+	var tags []string
+	var ports []int
+	var labels, app map[string]string
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "tags", Env: "TAGS", Store: &tags},
+		&cli.Option{Name: "ports", Env: "PORTS", Store: &ports},
+		&cli.Option{Name: "labels", Env: "LABELS", Store: &labels, Flags: cli.EnvKeys},
+		&cli.Option{Name: "app", Env: "APP", Store: &app},
+	)
+
+	env := map[string]string{
+		"TAGS":        "a,b",
+		"TAGS_1":      "d",
+		"TAGS_0":      "c",
+		"TAGS_10":     "e",
+		"TAGS_foo":    "ignored",
+		"PORTS_0":     "80",
+		"PORTS_1":     "443",
+		"LABELS":      "env=dev,owner=ops",
+		"LABELS_env":  "prod",
+		"LABELS_team": "core",
+		"APP":         "name=demo",
+		"APP_HOME":    "ignored",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+	defer func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	}()
+
+	_, err := p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, tags)
+	assert.Equal(t, []int{80, 443}, ports)
+	assert.Equal(t, map[string]string{"env": "prod", "owner": "ops", "team": "core"}, labels)
+	// Keyed variables are only merged into maps with the `EnvKeys` flag
+	assert.Equal(t, map[string]string{"name": "demo"}, app)
+
+	src, ok := p.Source("ports")
+	require.True(t, ok)
+	assert.Equal(t, "80,443", src.Raw)
+	assert.Equal(t, "PORTS_0, PORTS_1", src.Origin)
+}
+
+func TestStructuredEnvVarsDuplicateIndex(t *testing.T) {
+	var tags []string
+
+	p := cli.New(nil)
+	p.Add(&cli.Option{Name: "tags", Env: "TAGS", Store: &tags})
+
+	os.Setenv("TAGS_1", "a")
+	os.Setenv("TAGS_01", "b")
+	defer func() {
+		os.Unsetenv("TAGS_1")
+		os.Unsetenv("TAGS_01")
+	}()
+
+	_, err := p.Parse(nil, []string{})
+	require.NotNil(t, err)
+	assert.Equal(t, "while reading from store 'cli-env': environment variables "+
+		"'TAGS_01' and 'TAGS_1' provide the same index", err.Error())
+}
+
+func TestFromFile(t *testing.T) {
+	dir := t.TempDir()
+	secret := writeFile(t, filepath.Join(dir, "password"), "s3cr3t\n")
+	token := writeFile(t, filepath.Join(dir, "token"), "abc123\r\n")
+
+	newParser := func() (*cli.Parser, *string, *string) {
+		var password, token string
+		p := cli.New(nil)
+		p.Add(
+			&cli.Option{Name: "password", Env: "DB_PASSWORD", Flags: cli.FromFile, Store: &password},
+			&cli.Option{Name: "token", Env: "API_TOKEN", Flags: cli.FromFile, Store: &token},
+		)
+		return p, &password, &token
+	}
+
+	// Read from files named on the command line
+	p, password, _ := newParser()
+	_, err := p.Parse(nil, []string{"--password", "@" + secret})
+	require.Nil(t, err)
+	assert.Equal(t, "s3cr3t", *password)
+
+	src, ok := p.Source("password")
+	require.True(t, ok)
+	assert.Equal(t, secret, src.Origin)
+
+	// '@@' escapes a literal '@'
+	p, password, _ = newParser()
+	_, err = p.Parse(nil, []string{"--password", "@@home"})
+	require.Nil(t, err)
+	assert.Equal(t, "@home", *password)
+
+	// Read from files named by '<ENV>_FILE'
+	os.Setenv("API_TOKEN_FILE", token)
+	defer os.Unsetenv("API_TOKEN_FILE")
+
+	p, _, apiToken := newParser()
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, "abc123", *apiToken)
+
+	src, ok = p.Source("token")
+	require.True(t, ok)
+	assert.Equal(t, "cli-env", src.Source)
+	assert.Equal(t, token, src.Origin)
+
+	// Missing files are an error
+	p, _, _ = newParser()
+	_, err = p.Parse(nil, []string{"--password", "@" + filepath.Join(dir, "missing")})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "while reading value for option '--password'")
+
+	os.Setenv("API_TOKEN_FILE", filepath.Join(dir, "missing"))
+	p, _, _ = newParser()
+	_, err = p.Parse(nil, []string{})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "while reading 'API_TOKEN_FILE'")
+}
+
+func TestFromFileArgument(t *testing.T) {
+	secret := writeFile(t, filepath.Join(t.TempDir(), "password"), "s3cr3t\n")
+	os.Setenv("DB_PASSWORD_FILE", secret)
+	defer os.Unsetenv("DB_PASSWORD_FILE")
+
+	// Arguments with the flag are read from '<ENV>_FILE'
+	var password string
+	p := cli.New(nil)
+	p.Add(&cli.Argument{Name: "password", Env: "DB_PASSWORD", Flags: cli.FromFile, Store: &password})
+
+	_, err := p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, "s3cr3t", password)
+
+	src, ok := p.Source("password")
+	require.True(t, ok)
+	assert.Equal(t, secret, src.Origin)
+}
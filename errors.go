@@ -1,5 +1,7 @@
 package cli
 
+import "fmt"
+
 // Returns true if the error was because help flag was found during parsing
 func IsHelpError(err error) bool {
 	obj, ok := err.(isHelpError)
@@ -40,3 +42,24 @@ func (e *InvalidFlag) Error() string {
 func (e *InvalidFlag) IsInvalidFlag() bool {
 	return true
 }
+
+// Returned by Parse() and Watch() when a value fails one of the rule's `Validators`
+type ValidationError struct {
+	// The name of the rule
+	Name string
+	// The type of the rule; IE: 'option' or 'argument'
+	Type string
+	// The source which provided the value; IE: 'cli-args', 'cli-env' or the Source() of a store
+	Source string
+	// The value which failed validation, masked if the rule has the `Secret` flag
+	Value string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid value for %s '%s' from '%s': %s", e.Type, e.Name, e.Source, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
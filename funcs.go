@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"reflect"
@@ -269,6 +270,15 @@ func IsCharDevice(file *os.File) bool {
 	return (stat.Mode() & os.ModeCharDevice) == 0
 }
 
+// Returns the contents of the file without the trailing newline; used to read values from secret files
+func readValueFile(file string) (string, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(contents), "\r\n"), nil
+}
+
 func jsonToStringMap(value string) (map[string]string, error) {
 	result := make(map[string]string)
 	err := json.Unmarshal([]byte(value), &result)
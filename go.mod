@@ -1,7 +1,10 @@
 module github.com/harbor-pkgs/cli
 
+go 1.27.1
+
 require (
 	github.com/davecgh/go-spew v1.1.1
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/testify v1.2.2
 )
+
+require github.com/pmezard/go-difflib v1.0.0 // indirect
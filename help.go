@@ -3,6 +3,7 @@ package cli
 import (
 	"bytes"
 	"fmt"
+	"strings"
 )
 
 // Returns a string that contains documentation for each flag, argument and envvar provided
@@ -61,6 +62,11 @@ func (p *Parser) GenerateHelp() string {
 		result.WriteString(envVars)
 	}
 
+	if p.HasMode(LoadConfigFiles) {
+		result.WriteString("\nConfig Files:\n")
+		result.WriteString(p.generateConfigFilesSection())
+	}
+
 	if p.cfg.Epilog != "" {
 		result.WriteString("\n" + WordWrap(p.cfg.Epilog, 0, p.cfg.WordWrap))
 	}
@@ -78,7 +84,7 @@ func (p *Parser) GenerateHelp() string {
 //   # export ENDPOINTS=<str>,<str>
 func (p *Parser) GenerateEnvConfig() []byte {
 	var result bytes.Buffer
-	for _, rule := range p.rules {
+	for _, rule := range p.rulesWithEnvVars() {
 		if rule.EnvVar == "" {
 			continue
 		}
@@ -108,6 +114,30 @@ func (p *Parser) GenerateINIConfig() []byte {
 	return result.Bytes()
 }
 
+// Lists the config file search path in the order the files are loaded, marking
+// the files which were loaded by the last call to Parse() or which exist
+func (p *Parser) generateConfigFilesSection() string {
+	var result bytes.Buffer
+	files := p.configSearchPaths()
+	for _, file := range p.configFiles {
+		if !ContainsString(file, files, nil) {
+			files = append(files, file)
+		}
+	}
+
+	for _, file := range files {
+		switch {
+		case ContainsString(file, p.configFiles, nil):
+			result.WriteString(fmt.Sprintf("  %s (loaded)\n", file))
+		case fileExists(file):
+			result.WriteString(fmt.Sprintf("  %s (found)\n", file))
+		default:
+			result.WriteString(fmt.Sprintf("  %s\n", file))
+		}
+	}
+	return result.String()
+}
+
 func (p *Parser) generateUsage(flags Flags) string {
 	var result bytes.Buffer
 
@@ -128,13 +158,14 @@ func (p *Parser) generateHelpSection(flags Flags) string {
 	type helpMsg struct {
 		Flags   string
 		Message string
+		Choices []string
 	}
 	var result bytes.Buffer
 	var options []helpMsg
 
 	// Ask each rule to generate a Help message for the options
 	maxLen := 0
-	for _, rule := range p.rules {
+	for _, rule := range p.rulesWithEnvVars() {
 		if !rule.HasFlag(flags) {
 			continue
 		}
@@ -142,7 +173,7 @@ func (p *Parser) generateHelpSection(flags Flags) string {
 		if len(flags) > maxLen {
 			maxLen = len(flags)
 		}
-		options = append(options, helpMsg{flags, message})
+		options = append(options, helpMsg{flags, message, rule.generateChoicesHelp()})
 	}
 
 	// Set our indent length
@@ -152,6 +183,11 @@ func (p *Parser) generateHelpSection(flags Flags) string {
 	for _, opt := range options {
 		message := WordWrap(opt.Message, indent, p.cfg.WordWrap)
 		result.WriteString(fmt.Sprintf(flagFmt, opt.Flags, message))
+
+		// List the choices below the help message
+		for _, choice := range opt.Choices {
+			result.WriteString(strings.Repeat(" ", indent+2) + choice + "\n")
+		}
 	}
 	return result.String()
 }
@@ -0,0 +1,384 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The key/value API spoken by the HTTPStore
+type KVProtocol int
+
+const (
+	// Consul style API; 'GET <URL>/<Prefix>?recurse=true' returns '[{"Key": "...", "Value": "<base64>"}]'
+	ConsulProtocol KVProtocol = iota
+	// Etcd v3 JSON gateway style API; 'POST <URL>' with '{"key": "<base64>", "range_end": "<base64>"}'
+	// returns '{"kvs": [{"key": "<base64>", "value": "<base64>"}]}'
+	EtcdProtocol
+)
+
+type HTTPStoreConfig struct {
+	// The URL of the key/value API IE: 'http://localhost:8500/v1/kv' for consul
+	// or 'http://localhost:2379/v3/kv/range' for etcd
+	URL string
+	// The protocol spoken by the API, defaults to ConsulProtocol
+	Protocol KVProtocol
+	// Only keys with this prefix are retrieved, the prefix is removed from the key and any remaining
+	// '/' are replaced with '.' when matching rule names. IE: 'app/server/port' with the prefix
+	// 'app/' provides the value for the rule 'server.port'
+	Prefix string
+	// Headers included in each request, such as 'X-Consul-Token'
+	Header http.Header
+	// Headers whose values are masked when requests are logged, in addition to 'Authorization',
+	// 'Proxy-Authorization', 'Cookie' and any header of the form 'X-*-Token'
+	SecretHeaders []string
+	// The client used to make requests, defaults to http.DefaultClient
+	Client *http.Client
+	// Limits how long a request can take, in addition to any deadline on the context provided by the parser
+	Timeout time.Duration
+	// If provided, the values retrieved are written to this file and read from this file if the API
+	// cannot be reached. The file is written as plaintext JSON readable only by the owner. Values of
+	// rules with the `Secret` flag are not written unless `CacheSecrets` is true, such that secrets
+	// are not available from the cache while the API cannot be reached.
+	CacheFile string
+	// Write the values of rules with the `Secret` flag to the `CacheFile` in plaintext
+	CacheSecrets bool
+	// Requests are logged to this logger in the form of a curl command, defaults to DefaultLogger
+	Logger StdLogger
+}
+
+// A store which provides values from an HTTP key/value API such as consul or etcd. The values
+// for all rules are retrieved in a single request each time the parser reads from the store.
+type HTTPStore struct {
+	conf   HTTPStoreConfig
+	mutex  sync.Mutex
+	values map[string]httpValue
+	// The rules of the parser reading from the store, used to exclude secrets from the cache
+	rules ruleList
+}
+
+type httpValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func NewHTTPStore(conf HTTPStoreConfig) (FromStore, error) {
+	if conf.URL == "" {
+		return nil, errors.New("'URL' is required")
+	}
+	SetDefault(&conf.Client, http.DefaultClient)
+	SetDefault(&conf.Logger, DefaultLogger)
+	return &HTTPStore{conf: conf}, nil
+}
+
+func (h *HTTPStore) bind(ctx context.Context, p *Parser) error {
+	h.mutex.Lock()
+	h.rules = p.rules
+	h.mutex.Unlock()
+	return nil
+}
+
+func (h *HTTPStore) Source() string {
+	return "http-store"
+}
+
+// Retrieves the values for all keys under the prefix in a single request. If the request
+// fails and a cache file is configured the values are read from the cache file instead.
+func (h *HTTPStore) Prefetch(ctx context.Context, names []string) error {
+	if h.conf.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.conf.Timeout)
+		defer cancel()
+	}
+
+	values, err := h.fetch(ctx)
+	if err != nil {
+		if h.conf.CacheFile == "" {
+			return err
+		}
+		var cacheErr error
+		if values, cacheErr = h.readCache(); cacheErr != nil {
+			return fmt.Errorf("%s; and while reading cache file: %s", err, cacheErr)
+		}
+		h.conf.Logger.Printf("warning: %s; using cached values from '%s'", err, h.conf.CacheFile)
+	} else if h.conf.CacheFile != "" {
+		if err := h.writeCache(values); err != nil {
+			return fmt.Errorf("while writing cache file: %s", err)
+		}
+	}
+
+	h.mutex.Lock()
+	h.values = values
+	h.mutex.Unlock()
+	return nil
+}
+
+func (h *HTTPStore) lookup(ctx context.Context, name string) (httpValue, bool, error) {
+	h.mutex.Lock()
+	fetched := h.values != nil
+	h.mutex.Unlock()
+
+	// Fetch the values if Get() was called without Prefetch()
+	if !fetched {
+		if err := h.Prefetch(ctx, []string{name}); err != nil {
+			return httpValue{}, false, err
+		}
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	v, ok := h.values[name]
+	return v, ok, nil
+}
+
+func (h *HTTPStore) Get(ctx context.Context, name string, flags Flags) (interface{}, int, error) {
+	v, ok, err := h.lookup(ctx, name)
+	if err != nil || !ok {
+		return nil, 0, err
+	}
+	return convToKind([]string{v.Value}, flags, 1)
+}
+
+func (h *HTTPStore) Origin(ctx context.Context, name string) (string, string) {
+	v, ok, _ := h.lookup(ctx, name)
+	if !ok {
+		return "", ""
+	}
+	return v.Value, v.Key
+}
+
+func (h *HTTPStore) Keys(ctx context.Context) []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var results []string
+	for name := range h.values {
+		results = append(results, name)
+	}
+	sort.Strings(results)
+	return results
+}
+
+// Requests the values from the API returning them keyed by rule name
+func (h *HTTPStore) fetch(ctx context.Context) (map[string]httpValue, error) {
+	var req *http.Request
+	var payload []byte
+	var err error
+
+	switch h.conf.Protocol {
+	case EtcdProtocol:
+		payload, err = json.Marshal(map[string]string{
+			"key":       base64.StdEncoding.EncodeToString([]byte(h.conf.Prefix)),
+			"range_end": base64.StdEncoding.EncodeToString(prefixRangeEnd(h.conf.Prefix)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		req, err = http.NewRequest(http.MethodPost, h.conf.URL, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+	default:
+		url := strings.TrimSuffix(h.conf.URL, "/") + "/" + strings.TrimPrefix(h.conf.Prefix, "/") + "?recurse=true"
+		req, err = http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, values := range h.conf.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	if payload != nil {
+		h.conf.Logger.Printf("%s", CurlString(h.maskHeaders(req), &payload))
+	} else {
+		h.conf.Logger.Printf("%s", CurlString(h.maskHeaders(req), nil))
+	}
+
+	resp, err := h.conf.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("while fetching values from '%s': %s", h.conf.URL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("while reading response from '%s': %s", h.conf.URL, err)
+	}
+
+	// Consul responds with 404 when no keys exist under the prefix
+	if resp.StatusCode == http.StatusNotFound && h.conf.Protocol == ConsulProtocol {
+		return map[string]httpValue{}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("while fetching values from '%s': unexpected status '%s'", h.conf.URL, resp.Status)
+	}
+
+	var items []httpValue
+	if h.conf.Protocol == EtcdProtocol {
+		items, err = decodeEtcdResponse(body)
+	} else {
+		items, err = decodeConsulResponse(body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("while decoding response from '%s': %s", h.conf.URL, err)
+	}
+
+	results := make(map[string]httpValue, len(items))
+	for _, item := range items {
+		name := strings.Trim(strings.TrimPrefix(item.Key, h.conf.Prefix), "/")
+		// Skip the prefix itself and consul folders
+		if name == "" || strings.HasSuffix(item.Key, "/") {
+			continue
+		}
+		results[strings.Replace(name, "/", ".", -1)] = item
+	}
+	return results, nil
+}
+
+func decodeConsulResponse(body []byte) ([]httpValue, error) {
+	var resp []struct {
+		Key   string
+		Value *string
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	var results []httpValue
+	for _, item := range resp {
+		var value []byte
+		if item.Value != nil {
+			var err error
+			if value, err = base64.StdEncoding.DecodeString(*item.Value); err != nil {
+				return nil, fmt.Errorf("value for key '%s': %s", item.Key, err)
+			}
+		}
+		results = append(results, httpValue{Key: item.Key, Value: string(value)})
+	}
+	return results, nil
+}
+
+func decodeEtcdResponse(body []byte) ([]httpValue, error) {
+	var resp struct {
+		Kvs []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	var results []httpValue
+	for _, item := range resp.Kvs {
+		key, err := base64.StdEncoding.DecodeString(item.Key)
+		if err != nil {
+			return nil, fmt.Errorf("key '%s': %s", item.Key, err)
+		}
+		value, err := base64.StdEncoding.DecodeString(item.Value)
+		if err != nil {
+			return nil, fmt.Errorf("value for key '%s': %s", key, err)
+		}
+		results = append(results, httpValue{Key: string(key), Value: string(value)})
+	}
+	return results, nil
+}
+
+// Returns the end of the etcd key range which includes every key with the prefix provided
+func prefixRangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// The prefix is empty or all 0xff; request every key
+	return []byte{0}
+}
+
+func (h *HTTPStore) readCache() (map[string]httpValue, error) {
+	contents, err := ioutil.ReadFile(h.conf.CacheFile)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]httpValue)
+	if err := json.Unmarshal(contents, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Writes the values to a temporary file and renames it such that readers never see a partial cache
+// Returns a copy of the request with the values of sensitive headers masked, suitable for logging
+func (h *HTTPStore) maskHeaders(req *http.Request) *http.Request {
+	masked := *req
+	masked.Header = make(http.Header, len(req.Header))
+	for key, values := range req.Header {
+		if h.isSecretHeader(key) {
+			values = []string{secretMask}
+		}
+		masked.Header[key] = values
+	}
+	return &masked
+}
+
+func (h *HTTPStore) isSecretHeader(key string) bool {
+	key = http.CanonicalHeaderKey(key)
+	switch key {
+	case "Authorization", "Proxy-Authorization", "Cookie":
+		return true
+	}
+	if strings.HasPrefix(key, "X-") && strings.HasSuffix(key, "-Token") {
+		return true
+	}
+	for _, secret := range h.conf.SecretHeaders {
+		if http.CanonicalHeaderKey(secret) == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *HTTPStore) writeCache(values map[string]httpValue) error {
+	if !h.conf.CacheSecrets {
+		h.mutex.Lock()
+		rules := h.rules
+		h.mutex.Unlock()
+
+		cached := make(map[string]httpValue, len(values))
+		for name, v := range values {
+			if rule := rules.GetRule(name); rule != nil && rule.HasFlag(Secret) {
+				continue
+			}
+			cached[name] = v
+		}
+		values = cached
+	}
+
+	contents, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	tmp := h.conf.CacheFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, contents, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.conf.CacheFile)
+}
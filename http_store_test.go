@@ -0,0 +1,225 @@
+package cli_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harbor-pkgs/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func newConsulServer(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		assert.Equal(t, "/v1/kv/app/", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("recurse"))
+		assert.Equal(t, "secret-token", r.Header.Get("X-Consul-Token"))
+		fmt.Fprintf(w, `[
+			{"Key": "app/", "Value": null},
+			{"Key": "app/name", "Value": "%s"},
+			{"Key": "app/server/port", "Value": "%s"},
+			{"Key": "app/tags", "Value": "%s"}
+		]`, b64("goku"), b64("8080"), b64("a,b"))
+	}))
+}
+
+func TestHTTPStoreConsul(t *testing.T) {
+	var requests int
+	server := newConsulServer(t, &requests)
+	defer server.Close()
+
+	store, err := cli.NewHTTPStore(cli.HTTPStoreConfig{
+		URL:    server.URL + "/v1/kv",
+		Prefix: "app/",
+		Header: http.Header{"X-Consul-Token": []string{"secret-token"}},
+	})
+	require.Nil(t, err)
+
+	var name string
+	var port int
+	var tags []string
+	p := cli.New(&cli.Config{Mode: cli.ErrOnUnknownKeys})
+	p.Add(
+		&cli.Option{Name: "name", Store: &name},
+		&cli.Option{Name: "server.port", Store: &port},
+		&cli.Option{Name: "tags", Store: &tags},
+	)
+	p.AddStore(store)
+
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, "goku", name)
+	assert.Equal(t, 8080, port)
+	assert.Equal(t, []string{"a", "b"}, tags)
+
+	// All values are retrieved in a single request
+	assert.Equal(t, 1, requests)
+
+	src, ok := p.Source("server.port")
+	require.True(t, ok)
+	assert.Equal(t, "http-store", src.Source)
+	assert.Equal(t, "app/server/port", src.Origin)
+}
+
+func TestHTTPStoreEtcd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, b64("app/"), req["key"])
+		assert.Equal(t, b64("app0"), req["range_end"])
+		fmt.Fprintf(w, `{"kvs": [{"key": "%s", "value": "%s"}]}`, b64("app/name"), b64("vegeta"))
+	}))
+	defer server.Close()
+
+	store, err := cli.NewHTTPStore(cli.HTTPStoreConfig{
+		URL:      server.URL + "/v3/kv/range",
+		Protocol: cli.EtcdProtocol,
+		Prefix:   "app/",
+	})
+	require.Nil(t, err)
+
+	var name string
+	p := cli.New(nil)
+	p.Add(&cli.Option{Name: "name", Store: &name})
+	p.AddStore(store)
+
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, "vegeta", name)
+}
+
+func TestHTTPStoreCache(t *testing.T) {
+	var requests int
+	server := newConsulServer(t, &requests)
+	cache := filepath.Join(t.TempDir(), "cache.json")
+
+	newParser := func() (*cli.Parser, *string) {
+		var name, port string
+		store, err := cli.NewHTTPStore(cli.HTTPStoreConfig{
+			URL:       server.URL + "/v1/kv",
+			Prefix:    "app/",
+			Header:    http.Header{"X-Consul-Token": []string{"secret-token"}},
+			CacheFile: cache,
+		})
+		require.Nil(t, err)
+
+		p := cli.New(nil)
+		p.Add(
+			&cli.Option{Name: "name", Store: &name},
+			&cli.Option{Name: "server.port", Store: &port, Flags: cli.Secret},
+		)
+		p.AddStore(store)
+		return p, &name
+	}
+
+	// A successful request populates the cache
+	p, name := newParser()
+	_, err := p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, "goku", *name)
+
+	contents, err := ioutil.ReadFile(cache)
+	require.Nil(t, err)
+	assert.Contains(t, string(contents), "goku")
+	// Secrets are not written to the cache
+	assert.NotContains(t, string(contents), "8080")
+
+	// Values are read from the cache when the server is unavailable
+	server.Close()
+	p, name = newParser()
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, "goku", *name)
+}
+
+func TestHTTPStoreMasksHeaders(t *testing.T) {
+	var requests int
+	server := newConsulServer(t, &requests)
+	defer server.Close()
+
+	logger := &testLogger{}
+	store, err := cli.NewHTTPStore(cli.HTTPStoreConfig{
+		URL:    server.URL + "/v1/kv",
+		Prefix: "app/",
+		Header: http.Header{
+			"X-Consul-Token": []string{"secret-token"},
+			"Authorization":  []string{"Bearer abc123"},
+			"X-Api-Key":      []string{"key-xyz"},
+			"X-Request-Id":   []string{"req-1"},
+		},
+		SecretHeaders: []string{"x-api-key"},
+		Logger:        logger,
+	})
+	require.Nil(t, err)
+
+	var name string
+	p := cli.New(nil)
+	p.Add(&cli.Option{Name: "name", Store: &name})
+	p.AddStore(store)
+
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, "goku", name)
+
+	require.Len(t, logger.lines, 1)
+	assert.Contains(t, logger.lines[0], `-H "X-Consul-Token: ******"`)
+	assert.Contains(t, logger.lines[0], `-H "X-Request-Id: req-1"`)
+	for _, secret := range []string{"secret-token", "abc123", "key-xyz"} {
+		assert.NotContains(t, logger.lines[0], secret)
+	}
+}
+
+func TestHTTPStoreErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow/" {
+			time.Sleep(500 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	for _, test := range []struct {
+		name string
+		conf cli.HTTPStoreConfig
+		err  string
+	}{
+		{
+			name: "status",
+			conf: cli.HTTPStoreConfig{URL: server.URL},
+			err: fmt.Sprintf("while reading from store 'http-store': while fetching values "+
+				"from '%s': unexpected status '500 Internal Server Error'", server.URL),
+		},
+		{
+			name: "timeout",
+			conf: cli.HTTPStoreConfig{URL: server.URL, Prefix: "slow/", Timeout: 10 * time.Millisecond},
+			err:  "context deadline exceeded",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			store, err := cli.NewHTTPStore(test.conf)
+			require.Nil(t, err)
+
+			var name string
+			p := cli.New(nil)
+			p.Add(&cli.Option{Name: "name", Store: &name})
+			p.AddStore(store)
+
+			_, err = p.Parse(nil, []string{})
+			require.NotNil(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
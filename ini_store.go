@@ -1,20 +1,46 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sort"
 	"strings"
 )
 
 type keyValue struct {
 	Key   string
 	Value string
+	Line  int
 }
 
+// The section which holds values for the root parser, keys which
+// appear before any section header are also part of this section
+const iniGlobalSection = "global"
+
+// Prefix of sections which hold values for a profile, IE: '[profile.prod]'
+const iniProfilePrefix = "profile."
+
+// A store which provides values from an INI file. Keys in the '[global]' section, or keys which
+// appear before any section, provide values for the root parser. Keys in a section named after
+// a sub command, such as '[run]', provide values for that sub command's parser. Keys in a
+// '[profile.<name>]' section provide values when the profile is selected via `Config.ProfileOption`
+//
+//   debug=true
+//
+//   [run]
+//   image=alpine
+//
+//   [profile.prod]
+//   image=prod-image
+//
+// When resolving a key the most specific section wins; '[profile.prod.run]', then '[profile.prod]'
+// then '[run]' and finally '[global]'
 type INIStore struct {
-	values map[string][]keyValue
+	sections map[string]map[string][]keyValue
+	name     string
 }
 
 func NewIniStore(r io.Reader) (FromStore, error) {
@@ -23,73 +49,208 @@ func NewIniStore(r io.Reader) (FromStore, error) {
 		return nil, err
 	}
 
-	values := make(map[string][]keyValue)
-	lines := strings.Split(string(contents), "\n")
-	var count int
-	for _, line := range lines {
-		// Skip comments or malformed lines
-		if len(line) == 0 || line[0] == '#' || line[0] == ' ' || line[0] == '\n' {
+	name := readerName(r)
+	sections := map[string]map[string][]keyValue{
+		iniGlobalSection: make(map[string][]keyValue),
+	}
+	values := sections[iniGlobalSection]
+
+	lines := strings.Split(strings.Replace(string(contents), "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := strings.TrimSpace(lines[i])
+
+		// Skip empty lines and comments
+		if line == "" || isIniComment(line) {
 			continue
 		}
 
-		// Split the line into key=value or "key"="value"
-		var inQuote bool
-		var eRune rune
-		parts := strings.FieldsFunc(line, func(c rune) bool {
+		// Join lines which end with a '\' continuation
+		for hasIniContinuation(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimSpace(lines[i])
+		}
+
+		// Start a new section
+		if line[0] == '[' {
+			section, err := parseIniSection(line)
+			if err != nil {
+				return nil, fmt.Errorf("INI parse error at %s: %s", fileOrigin(name, []int{lineNum}), err)
+			}
+			if _, ok := sections[section]; !ok {
+				sections[section] = make(map[string][]keyValue)
+			}
+			values = sections[section]
+			continue
+		}
+
+		key, value, err := parseIniKeyValue(line)
+		if err != nil {
+			return nil, fmt.Errorf("INI parse error at %s: %s", fileOrigin(name, []int{lineNum}), err)
+		}
+		values[key] = append(values[key], keyValue{Key: key, Value: value, Line: lineNum})
+	}
+	return &INIStore{sections: sections, name: name}, nil
+}
+
+func isIniComment(s string) bool {
+	return s[0] == '#' || s[0] == ';'
+}
+
+// Returns true if the line ends with an un-escaped '\'
+func hasIniContinuation(line string) bool {
+	var count int
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// Parse a '[section]' header, returning the name of the section
+func parseIniSection(line string) (string, error) {
+	idx := strings.IndexByte(line, ']')
+	if idx == -1 {
+		return "", fmt.Errorf("expected ']' to close section header")
+	}
+
+	trailing := strings.TrimSpace(line[idx+1:])
+	if trailing != "" && !isIniComment(trailing) {
+		return "", fmt.Errorf("unexpected '%s' after section header", trailing)
+	}
+
+	section := strings.TrimSpace(line[1:idx])
+	if section == "" {
+		return "", fmt.Errorf("section header has no name")
+	}
+	return section, nil
+}
+
+// Parse a 'key=value' line where the key or value can be quoted. Keys without an '=' have an empty value
+func parseIniKeyValue(line string) (string, string, error) {
+	key, rest, err := readIniToken(line, true)
+	if err != nil {
+		return "", "", err
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("expected a key before '='")
+	}
+
+	rest = strings.TrimSpace(rest)
+	if rest == "" || isIniComment(rest) {
+		return key, "", nil
+	}
+	if rest[0] != '=' {
+		return "", "", fmt.Errorf("expected '=' after key '%s' but found '%s'", key, rest)
+	}
+
+	value, rest, err := readIniToken(rest[1:], false)
+	if err != nil {
+		return "", "", err
+	}
+
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !isIniComment(rest) {
+		return "", "", fmt.Errorf("unexpected '%s' after value for key '%s'", rest, key)
+	}
+	return key, value, nil
+}
+
+// Reads a key or value from the beginning of 's' returning the un-escaped token and the remainder
+// of 's'. Keys end at the first un-quoted '=' and values end at an inline comment which begins
+// with ' #' or ' ;'. Double quoted and un-quoted tokens support backslash escapes, single quoted
+// tokens are read literally.
+func readIniToken(s string, isKey bool) (string, string, error) {
+	trimmed := strings.TrimLeft(s, " \t")
+	// A comment may begin immediately if the token was preceded by whitespace, IE: 'key = ; comment'
+	spaced := len(trimmed) != len(s)
+	s = trimmed
+	var buf bytes.Buffer
+
+	if len(s) != 0 && (s[0] == '"' || s[0] == '\'') {
+		quote := s[0]
+		for i := 1; i < len(s); i++ {
+			c := s[i]
 			switch {
-			case c == '"' || c == '\'':
-				// If we are inside a quote
-				if inQuote {
-					// And we match the same rune that began the quote
-					if c == eRune {
-						inQuote = !inQuote
-					}
-				} else {
-					// If we are outside a quote, record the start rune
-					inQuote = !inQuote
-					eRune = c
-				}
-				return false
-			case inQuote:
-				return false
+			case c == quote:
+				return buf.String(), s[i+1:], nil
+			case c == '\\' && quote == '"' && i+1 < len(s):
+				i++
+				buf.WriteString(unescapeIni(s[i], isKey))
 			default:
-				switch c {
-				case '=':
-					return true
-				case '\n':
-					return true
-				default:
-					return false
-				}
+				buf.WriteByte(c)
 			}
-		})
+		}
+		return "", "", fmt.Errorf("unterminated quote; expected closing %c", quote)
+	}
 
-		if len(parts) == 0 {
-			return nil, fmt.Errorf("unknown parsing error on line '%d'", count)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			buf.WriteString(unescapeIni(s[i], isKey))
+		case isKey && c == '=':
+			return strings.TrimSpace(buf.String()), s[i:], nil
+		case (c == '#' || c == ';') && (i == 0 && spaced || i != 0 && (s[i-1] == ' ' || s[i-1] == '\t')):
+			return strings.TrimSpace(buf.String()), s[i:], nil
+		default:
+			buf.WriteByte(c)
 		}
+	}
+	return strings.TrimSpace(buf.String()), "", nil
+}
 
-		// Determine if the key has a value
-		var key, value string
-		if len(parts) > 1 {
-			key = strings.Trim(parts[0], `"'`)
-			value = strings.Trim(parts[1], `"'`)
-		} else {
-			key = parts[0]
+// Returns the character represented by the escape sequence. Unknown escape sequences are
+// preserved such that escaped delimiters like '\,' are available to `ToStringMap()`
+func unescapeIni(c byte, isKey bool) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 't':
+		return "\t"
+	case 'r':
+		return "\r"
+	case '\\', '"', '\'', '#', ';', ' ':
+		return string(c)
+	case '=':
+		if isKey {
+			return "="
 		}
+	}
+	return "\\" + string(c)
+}
 
-		// Append or set the value
-		if _, ok := values[key]; ok {
-			values[key] = append(values[key], keyValue{Key: key, Value: value})
-		} else {
-			values[key] = []keyValue{{Key: key, Value: value}}
+// Returns the names of the sections visible to the requesting parser, most specific first
+func (kv *INIStore) sectionNames(ctx context.Context) []string {
+	scope := ScopeFromContext(ctx)
+	command := strings.Join(scope.Commands, ".")
+
+	var names []string
+	if scope.Profile != "" {
+		if command != "" {
+			names = append(names, iniProfilePrefix+scope.Profile+"."+command)
 		}
+		names = append(names, iniProfilePrefix+scope.Profile)
+	}
+	if command != "" {
+		names = append(names, command)
 	}
-	fmt.Printf("Values: %+v\n", values)
-	return &INIStore{values: values}, nil
+	return append(names, iniGlobalSection)
+}
+
+// Returns the values for the key from the most specific section
+// which contains the key given the scope of the requesting parser
+func (kv *INIStore) lookup(ctx context.Context, key string) ([]keyValue, bool) {
+	for _, name := range kv.sectionNames(ctx) {
+		if kvs, ok := kv.sections[name][key]; ok {
+			return kvs, true
+		}
+	}
+	return nil, false
 }
 
 func (kv *INIStore) Get(ctx context.Context, key string, flags Flags) (interface{}, int, error) {
-	kvs, ok := kv.values[key]
+	kvs, ok := kv.lookup(ctx, key)
 	if !ok {
 		return "", 0, nil
 	}
@@ -113,3 +274,72 @@ func (kv *INIStore) Source() string {
 	// TODO: Make this something users can reference
 	return "key-value-store"
 }
+
+// Returns the keys in the sections visible to the requesting parser. The root parser is also
+// provided the keys of every other section prefixed by the sub command the section belongs to,
+// such as 'run.image' for the key 'image' in the '[run]' or '[profile.prod.run]' sections, such
+// that keys in sections which match no sub command are reported as unknown
+func (kv *INIStore) Keys(ctx context.Context) []string {
+	var results []string
+	for _, name := range kv.sectionNames(ctx) {
+		for key := range kv.sections[name] {
+			if !ContainsString(key, results, nil) {
+				results = append(results, key)
+			}
+		}
+	}
+	for key := range kv.hiddenKeys(ctx) {
+		if !ContainsString(key, results, nil) {
+			results = append(results, key)
+		}
+	}
+	sort.Strings(results)
+	return results
+}
+
+// Returns the keys of sections which are not visible to the root parser keyed by the name
+// reported by Keys(). Returns nothing if the requesting parser is a sub command's parser.
+func (kv *INIStore) hiddenKeys(ctx context.Context) map[string][]keyValue {
+	if len(ScopeFromContext(ctx).Commands) != 0 {
+		return nil
+	}
+
+	visible := kv.sectionNames(ctx)
+	results := make(map[string][]keyValue)
+	for section, values := range kv.sections {
+		if ContainsString(section, visible, nil) {
+			continue
+		}
+		// Profile sections hold keys for the root parser or the sub command which follows the profile name
+		command := section
+		if strings.HasPrefix(section, iniProfilePrefix) {
+			command = ""
+			if parts := strings.SplitN(strings.TrimPrefix(section, iniProfilePrefix), ".", 2); len(parts) == 2 {
+				command = parts[1]
+			}
+		}
+		for key, kvs := range values {
+			if command != "" {
+				key = command + "." + key
+			}
+			if _, ok := results[key]; !ok {
+				results[key] = kvs
+			}
+		}
+	}
+	return results
+}
+
+func (kv *INIStore) Origin(ctx context.Context, key string) (string, string) {
+	var values []string
+	var lines []int
+	kvs, ok := kv.lookup(ctx, key)
+	if !ok {
+		kvs = kv.hiddenKeys(ctx)[key]
+	}
+	for _, kv := range kvs {
+		values = append(values, kv.Value)
+		lines = append(lines, kv.Line)
+	}
+	return strings.Join(values, ","), fileOrigin(kv.name, lines)
+}
@@ -175,3 +175,256 @@ func TestCastFile(t *testing.T) {
 	assert.Equal(t, []bool{false, true, false}, boolSlice)
 	assert.Equal(t, map[string]bool{"on": true, "off": false, "yes": false}, boolMap)
 }
+
+var sectionFile = `
+debug=true
+image=default
+
+[global]
+user=thrall
+
+[run]
+image=alpine
+
+[profile.prod]
+debug=false
+
+[profile.prod.run]
+image=prod-image
+`
+
+func TestIniSections(t *testing.T) {
+	kv, err := cli.NewIniStore(bytes.NewReader([]byte(sectionFile)))
+	require.Nil(t, err)
+
+	tests := []struct {
+		scope cli.Scope
+		key   string
+		value string
+	}{
+		{scope: cli.Scope{}, key: "debug", value: "true"},
+		{scope: cli.Scope{}, key: "user", value: "thrall"},
+		{scope: cli.Scope{}, key: "image", value: "default"},
+		{scope: cli.Scope{Commands: []string{"run"}}, key: "image", value: "alpine"},
+		{scope: cli.Scope{Commands: []string{"run"}}, key: "user", value: "thrall"},
+		{scope: cli.Scope{Profile: "prod"}, key: "debug", value: "false"},
+		{scope: cli.Scope{Profile: "prod"}, key: "image", value: "default"},
+		{scope: cli.Scope{Commands: []string{"run"}, Profile: "prod"}, key: "image", value: "prod-image"},
+		{scope: cli.Scope{Commands: []string{"run"}, Profile: "dev"}, key: "image", value: "alpine"},
+	}
+
+	for _, test := range tests {
+		ctx := cli.ContextWithScope(context.Background(), test.scope)
+		value, count, err := kv.Get(ctx, test.key, cli.ScalarKind)
+		require.Nil(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, test.value, value, "%+v %s", test.scope, test.key)
+	}
+}
+
+func TestIniProfileOption(t *testing.T) {
+	var profile string
+	var debug bool
+
+	p := cli.New(&cli.Config{ProfileOption: "profile"})
+	p.Add(
+		&cli.Option{Name: "profile", Store: &profile},
+		&cli.Option{Name: "debug", Store: &debug},
+	)
+
+	kv, err := cli.NewIniStore(bytes.NewReader([]byte(sectionFile)))
+	require.Nil(t, err)
+	p.AddStore(kv)
+
+	// Given no profile
+	retCode, err := p.Parse(nil, []string{})
+
+	// Then
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, true, debug)
+
+	// Given a profile
+	retCode, err = p.Parse(nil, []string{"--profile", "prod"})
+
+	// Then
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "prod", profile)
+	assert.Equal(t, false, debug)
+}
+
+func TestIniSubCommand(t *testing.T) {
+	var profile, image, user string
+	var debug bool
+
+	p := cli.New(&cli.Config{ProfileOption: "profile"})
+	p.Add(
+		&cli.Option{Name: "profile", Store: &profile},
+		&cli.Option{Name: "debug", Store: &debug},
+		&cli.Option{Name: "user", Store: &user},
+		&cli.Command{Name: "run", Help: "run an image", Func: func(ctx context.Context, sub *cli.Parser) (int, error) {
+			sub.Add(&cli.Option{Name: "image", Store: &image})
+			return sub.Parse(ctx, nil)
+		}},
+	)
+
+	kv, err := cli.NewIniStore(bytes.NewReader([]byte(sectionFile)))
+	require.Nil(t, err)
+	p.AddStore(kv)
+
+	// Given the sub command
+	retCode, err := p.Parse(nil, []string{"run"})
+
+	// Then the '[run]' section applies
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "alpine", image)
+	assert.Equal(t, "thrall", user)
+	assert.Equal(t, true, debug)
+
+	// The root parser reports the values stored by the sub command
+	src, ok := p.Source("image")
+	require.True(t, ok)
+	assert.Equal(t, "alpine", src.Raw)
+	src, ok = p.Source("user")
+	require.True(t, ok)
+	assert.Equal(t, "thrall", src.Raw)
+
+	// Given the sub command and a profile
+	retCode, err = p.Parse(nil, []string{"--profile", "prod", "run"})
+
+	// Then the '[profile.prod.run]' section applies
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "prod-image", image)
+	assert.Equal(t, false, debug)
+}
+
+func TestIniGlobalCommand(t *testing.T) {
+	p := cli.New(nil)
+	p.Add(&cli.Command{Name: "global", Func: func(ctx context.Context, sub *cli.Parser) (int, error) {
+		return sub.Parse(ctx, nil)
+	}})
+
+	_, err := p.Parse(nil, []string{"global"})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "refusing to add command 'global'; the name is reserved for the '[global]' INI section")
+}
+
+var robustFile = `
+; a semicolon comment
+  indented = value with spaces   # inline comment
+equals = "a=b=c" ; quoted with inline comment
+url = http://example.com/?a=b
+escaped = tab\there\#not-a-comment
+literal = 'no\tescape'
+list = one,\
+       two,\
+       three
+empty = ; only a comment
+semicolon =;not-a-comment
+`
+
+func TestIniParsing(t *testing.T) {
+	kv, err := cli.NewIniStore(bytes.NewReader([]byte(robustFile)))
+	require.Nil(t, err)
+
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{key: "indented", value: "value with spaces"},
+		{key: "equals", value: "a=b=c"},
+		{key: "url", value: "http://example.com/?a=b"},
+		{key: "escaped", value: "tab\there#not-a-comment"},
+		{key: "literal", value: `no\tescape`},
+		{key: "list", value: "one,two,three"},
+		{key: "empty", value: ""},
+		{key: "semicolon", value: ";not-a-comment"},
+	}
+
+	for _, test := range tests {
+		value, count, err := kv.Get(context.TODO(), test.key, cli.ScalarKind)
+		require.Nil(t, err)
+		assert.Equal(t, 1, count, test.key)
+		assert.Equal(t, test.value, value, test.key)
+	}
+
+	// Continuations should report the line the key began on
+	_, origin := kv.(cli.OriginStore).Origin(context.TODO(), "list")
+	assert.Equal(t, "line 8", origin)
+}
+
+func TestIniParseErrors(t *testing.T) {
+	tests := []struct {
+		file string
+		err  string
+	}{
+		{file: "# comment\n\nfoo=\"bar", err: "INI parse error at line 3: unterminated quote; expected closing \""},
+		{file: "foo=bar\n[section", err: "INI parse error at line 2: expected ']' to close section header"},
+		{file: "foo=\"bar\" bang", err: "INI parse error at line 1: unexpected 'bang' after value for key 'foo'"},
+		{file: "=bar", err: "INI parse error at line 1: expected a key before '='"},
+	}
+
+	for _, test := range tests {
+		_, err := cli.NewIniStore(bytes.NewReader([]byte(test.file)))
+		require.NotNil(t, err)
+		assert.Equal(t, test.err, err.Error())
+	}
+}
+
+func TestIniStrictMode(t *testing.T) {
+	var power int
+
+	p := cli.New(&cli.Config{Mode: cli.ErrOnUnknownKeys})
+	p.Add(&cli.Option{Name: "power-level", Store: &power})
+
+	kv, err := cli.NewIniStore(bytes.NewReader([]byte("power-level=1\npwer-level=9001\n")))
+	require.Nil(t, err)
+	p.AddStore(kv)
+
+	// Given
+	retCode, err := p.Parse(nil, []string{})
+
+	// Then
+	require.NotNil(t, err)
+	assert.Equal(t, cli.ErrorRetCode, retCode)
+	assert.Equal(t, "unknown key 'pwer-level' in store 'key-value-store' at line 2; "+
+		"did you mean 'power-level'?", err.Error())
+}
+
+func TestIniStrictModeSections(t *testing.T) {
+	var profile, image string
+	var debug bool
+
+	newParser := func(file string) *cli.Parser {
+		p := cli.New(&cli.Config{Mode: cli.ErrOnUnknownKeys, ProfileOption: "profile"})
+		p.Add(
+			&cli.Option{Name: "profile", Store: &profile},
+			&cli.Option{Name: "debug", Store: &debug},
+			&cli.Command{Name: "run", Help: "run an image", Func: func(ctx context.Context, sub *cli.Parser) (int, error) {
+				sub.Add(&cli.Option{Name: "image", Store: &image})
+				return sub.Parse(ctx, nil)
+			}},
+		)
+		kv, err := cli.NewIniStore(bytes.NewReader([]byte(file)))
+		require.Nil(t, err)
+		p.AddStore(kv)
+		return p
+	}
+
+	// Sections which match a sub command or profile are known
+	file := "debug=true\n[run]\nimage=alpine\n[profile.prod]\ndebug=false\n[profile.prod.run]\nimage=prod\n"
+	_, err := newParser(file).Parse(nil, []string{})
+	require.Nil(t, err)
+
+	// Sections which match no sub command are unknown
+	_, err = newParser("debug=true\n[rnu]\nimage=alpine\n").Parse(nil, []string{})
+	require.NotNil(t, err)
+	assert.Equal(t, "unknown key 'rnu.image' in store 'key-value-store' at line 3", err.Error())
+
+	_, err = newParser("[profile.prod.rnu]\nimage=alpine\n").Parse(nil, []string{})
+	require.NotNil(t, err)
+	assert.Equal(t, "unknown key 'rnu.image' in store 'key-value-store' at line 2", err.Error())
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// A store which provides values from a JSON document. Top level keys and dotted paths into
+// nested objects are mapped to rule names, such that the rule 'server.port' will match
+//
+//   {"server": {"port": 8080}}
+//
+// JSON arrays provide values for `SliceKind` rules and objects provide values for `MapKind` rules.
+type JSONStore struct {
+	treeStore
+}
+
+func NewJSONStore(r io.Reader) (FromStore, error) {
+	decoder := json.NewDecoder(r)
+	// Preserve the numbers as they were written
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("while decoding JSON: %s", err)
+	}
+
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object at the top level but found '%T'", doc)
+	}
+
+	return &JSONStore{
+		treeStore: treeStore{
+			source: "json-store",
+			format: "JSON",
+			file:   readerName(r),
+			root:   normalizeJSON(root).(map[string]interface{}),
+		},
+	}, nil
+}
+
+// Converts the decoded JSON values into the string leaves expected by `treeStore`
+func normalizeJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJSON(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJSON(item)
+		}
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprintf("%t", v)
+	}
+	return value
+}
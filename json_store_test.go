@@ -0,0 +1,86 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/harbor-pkgs/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jsonFile = `{
+	"name": "thrall",
+	"power-level": 9001,
+	"debug": true,
+	"weapons": ["axe", "hammer"],
+	"labels": {"env": "prod", "team": "horde"},
+	"server": {
+		"port": 8080,
+		"tags": ["a", {"b": "c"}]
+	},
+	"server.bind": "localhost"
+}`
+
+func TestJSONStore(t *testing.T) {
+	var name, bind string
+	var power, port int
+	var debug bool
+	var weapons []string
+	var labels map[string]string
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "name", Store: &name},
+		&cli.Option{Name: "power-level", Store: &power},
+		&cli.Option{Name: "debug", Store: &debug},
+		&cli.Option{Name: "weapons", Store: &weapons},
+		&cli.Option{Name: "labels", Store: &labels},
+		&cli.Option{Name: "server.port", Store: &port},
+		&cli.Option{Name: "server.bind", Store: &bind},
+	)
+
+	store, err := cli.NewJSONStore(bytes.NewReader([]byte(jsonFile)))
+	require.Nil(t, err)
+	p.AddStore(store)
+
+	// Given
+	retCode, err := p.Parse(nil, []string{})
+
+	// Then
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "thrall", name)
+	assert.Equal(t, 9001, power)
+	assert.Equal(t, true, debug)
+	assert.Equal(t, []string{"axe", "hammer"}, weapons)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "horde"}, labels)
+	assert.Equal(t, 8080, port)
+	assert.Equal(t, "localhost", bind)
+
+	prov, ok := p.Source("server.port")
+	require.True(t, ok)
+	assert.Equal(t, cli.Provenance{Name: "server.port", Source: "json-store", Raw: "8080", Origin: "server.port"}, prov)
+}
+
+func TestJSONStoreMismatch(t *testing.T) {
+	store, err := cli.NewJSONStore(bytes.NewReader([]byte(jsonFile)))
+	require.Nil(t, err)
+
+	_, _, err = store.Get(context.TODO(), "weapons", cli.ScalarKind)
+	require.NotNil(t, err)
+	assert.Equal(t, "JSON path 'weapons': expected a scalar value but found an array", err.Error())
+
+	_, _, err = store.Get(context.TODO(), "server", cli.SliceKind)
+	require.NotNil(t, err)
+	assert.Equal(t, "JSON path 'server': expected an array or scalar value but found an object", err.Error())
+
+	_, _, err = store.Get(context.TODO(), "server.tags", cli.SliceKind)
+	require.NotNil(t, err)
+	assert.Equal(t, "JSON path 'server.tags[1]': expected a scalar value but found an object", err.Error())
+
+	_, err = cli.NewJSONStore(bytes.NewReader([]byte(`["not", "an", "object"]`)))
+	require.NotNil(t, err)
+	assert.Equal(t, "expected a JSON object at the top level but found '[]interface {}'", err.Error())
+}
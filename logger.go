@@ -1,7 +1,18 @@
 package cli
 
+import (
+	"log"
+	"os"
+)
+
 var DefaultLogger = &NullLogger{}
 
+// Returns a logger which writes to stderr; the default `Config.Logger` such that warnings
+// are visible to the user unless the application provides its own logger
+func StderrLogger() StdLogger {
+	return log.New(os.Stderr, "", 0)
+}
+
 // We only need part of the standard logging functions
 type StdLogger interface {
 	Print(...interface{})
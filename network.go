@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+)
+
+// A host and port pair such as 'localhost:8080' or '[::1]:8080', the host may be a name or an IP address
+type HostPort struct {
+	Host string
+	Port int
+}
+
+// Returns the host and port in the form 'host:port', IPv6 hosts are enclosed in brackets
+func (h HostPort) String() string {
+	return net.JoinHostPort(h.Host, strconv.Itoa(h.Port))
+}
+
+func parseIP(value string) (interface{}, error) {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("'%s' is not a valid IP address; expected '192.168.1.1' or '::1'", value)
+	}
+	return ip, nil
+}
+
+func parseIPNet(value string) (interface{}, error) {
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid CIDR; expected '10.0.0.0/8' or 'fd00::/8'", value)
+	}
+	return ipNet, nil
+}
+
+func parseURL(value string) (interface{}, error) {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" {
+		return nil, fmt.Errorf("'%s' is not a valid URL; expected 'scheme://host/path'", value)
+	}
+	return u, nil
+}
+
+func parseAddrPort(value string) (interface{}, error) {
+	addr, err := netip.ParseAddrPort(value)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid IP and port; expected '127.0.0.1:80' or '[::1]:80'", value)
+	}
+	return addr, nil
+}
+
+func parseHostPort(value string) (interface{}, error) {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil || host == "" {
+		return nil, fmt.Errorf("'%s' is not a valid host and port; expected 'localhost:80'", value)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid host and port; port must be between 0 and 65535", value)
+	}
+	return HostPort{Host: host, Port: int(p)}, nil
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Returns a parse function for signed integers of the size provided. Accepts the
+// literal prefixes '0x', '0o', '0b' and '0' for hex, octal, binary and octal values
+func parseIntBits(bits int, name string) func(string) (interface{}, error) {
+	return func(value string) (interface{}, error) {
+		i, err := strconv.ParseInt(value, 0, bits)
+		if err != nil {
+			return nil, numberError(err, value, name, "an integer")
+		}
+		return i, nil
+	}
+}
+
+// Returns a parse function for unsigned integers of the size provided
+func parseUintBits(bits int, name string) func(string) (interface{}, error) {
+	return func(value string) (interface{}, error) {
+		i, err := strconv.ParseUint(value, 0, bits)
+		if err != nil {
+			return nil, numberError(err, value, name, "an unsigned integer")
+		}
+		return i, nil
+	}
+}
+
+func parseFloat32(value string) (interface{}, error) {
+	f, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return nil, numberError(err, value, "float32", "a number")
+	}
+	return f, nil
+}
+
+// Parses file permissions such as '0644', '644' or '0o644' as octal
+func parseFileMode(value string) (interface{}, error) {
+	mode, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(value, "0o"), "0O"), 8, 32)
+	if err != nil || mode > uint64(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky) {
+		return nil, fmt.Errorf("'%s' is not a valid file mode; expected octal permissions such as '0644'", value)
+	}
+	// Map the unix setuid, setgid and sticky bits to their `os.FileMode` equivalents
+	result := os.FileMode(mode) & os.ModePerm
+	for bit, flag := range map[uint64]os.FileMode{04000: os.ModeSetuid, 02000: os.ModeSetgid, 01000: os.ModeSticky} {
+		if mode&bit != 0 {
+			result |= flag
+		}
+	}
+	return result, nil
+}
+
+func numberError(err error, value, name, expected string) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return fmt.Errorf("'%s' is out of range for %s", value, name)
+	}
+	return fmt.Errorf("'%s' is not %s", value, expected)
+}
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+// Creates the StoreFunc for a '[]byte' destination. Values are stored as is unless
+// the rule has the `Hex` or `Base64` flag, in which case the value is decoded
+func newBytesStoreFunc(r *rule, dest interface{}) error {
+	if r.HasFlag(Hex) && r.HasFlag(Base64) {
+		return errors.New("cannot use both the Hex and Base64 flags")
+	}
+
+	ptr := dest.(*[]byte)
+	r.Usage = "<string>"
+	decode := func(value string) ([]byte, error) {
+		return []byte(value), nil
+	}
+
+	switch {
+	case r.HasFlag(Hex):
+		r.Usage = "<hex>"
+		decode = func(value string) ([]byte, error) {
+			b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X"))
+			if err != nil {
+				return nil, fmt.Errorf("'%s' is not valid hex", value)
+			}
+			return b, nil
+		}
+	case r.HasFlag(Base64):
+		r.Usage = "<base64>"
+		decode = func(value string) ([]byte, error) {
+			// Accept both the standard and URL alphabets, with or without padding
+			for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding,
+				base64.URLEncoding, base64.RawURLEncoding} {
+				if b, err := enc.DecodeString(value); err == nil {
+					return b, nil
+				}
+			}
+			return nil, fmt.Errorf("'%s' is not valid base64", value)
+		}
+	}
+
+	r.SetFlag(ScalarKind, true)
+	r.StoreFuncs = append(r.StoreFuncs, func(value interface{}, count int) error {
+		b, err := decode(value.(string))
+		if err != nil {
+			return err
+		}
+		*ptr = b
+		return nil
+	})
+	r.Dests = append(r.Dests, dest)
+	return nil
+}
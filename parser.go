@@ -9,6 +9,8 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -20,6 +22,7 @@ const (
 	cliSource     = "cli-args"
 	envSource     = "cli-env"
 	defaultSource = "cli-default"
+	configSource  = "cli-config"
 )
 
 // This is just so Add() won't complain we didn't provide a 'IsSet' for our auto added Help option
@@ -43,6 +46,15 @@ const (
 	NoHelp
 	// Don't display help message when ParseOrExit() encounters an error
 	NoHelpOnError
+	// Keys in stores which match no option or argument result in an error. Only applies
+	// to stores which implement the `KeyLister` interface such as INIStore
+	ErrOnUnknownKeys
+	// Add a --config, -c option and a <NAME>_CONFIG environment variable and read values from
+	// the config files found in the search path. See `Config.ConfigPaths` for the search path.
+	LoadConfigFiles
+	// Options without an 'Env' are assigned an environment variable derived from `Config.EnvPrefix`
+	// the sub command and the option name; IE 'DEMO_RUN_IMAGE' for the option 'image' of the sub command 'run'
+	AutoEnvVars
 )
 
 type Config struct {
@@ -50,7 +62,8 @@ type Config struct {
 	WordWrap int
 	// Custom usage provided by the user
 	Usage string
-	// Prefix applied to all to rules that match environment variables
+	// Prefix applied to the environment variable names derived by `AutoEnvVars`; IE 'DEMO' derives
+	// 'DEMO_POWER_LEVEL' for the option 'power-level'. Explicit 'Env' names are not prefixed
 	EnvPrefix string
 	// A description of the application
 	Desc string
@@ -58,12 +71,34 @@ type Config struct {
 	Epilog string
 	// The name of the application
 	Name string
-	// TODO: If defined will log parse and type errors to this logger
+	// Warnings, such as a `Secret` value provided on the command line, are logged
+	// to this logger. Defaults to `StderrLogger()`, use `DefaultLogger` to discard warnings
 	Logger StdLogger
 	// Provide an error function, defaults to a function that prints the error to stdout and panics
 	ErrorFunc ErrorFunc
 	// Represents the parsers mode which dictates how the parser reacts to input
 	Mode Mode
+	// The name of an option or argument whose value selects the active profile. Stores
+	// which support profiles, such as the INIStore, prefer values from the active profile
+	ProfileOption string
+	// The config files searched when `LoadConfigFiles` is set, in the order they are loaded; files which
+	// do not exist are skipped. Defaults to '/etc/<name>/config.{ini,toml,json}' followed by
+	// '$XDG_CONFIG_HOME/<name>/config.{ini,toml,json}' and finally './.<name>rc'
+	ConfigPaths []string
+	// The layouts attempted in order when parsing `time.Time` values, defaults to `DefaultTimeLayouts()`.
+	// Include `UnixLayout` to accept the number of seconds since the unix epoch
+	TimeLayouts []string
+	// The clock relative times such as '-2h' or 'yesterday' are resolved against, defaults to `time.Now`
+	Now func() time.Time
+	// How often Watch() checks stores for changes, defaults to 1 second
+	ReloadInterval time.Duration
+	// Watch() re-reads every store each time a signal is received. Watch() does not register for
+	// any signals itself, such that the application remains in control of signal handling
+	//
+	//   hup := make(chan os.Signal, 1)
+	//   signal.Notify(hup, syscall.SIGHUP)
+	//   p := cli.New(&cli.Config{ReloadSignal: hup})
+	ReloadSignal <-chan os.Signal
 }
 
 type Parser struct {
@@ -73,14 +108,30 @@ type Parser struct {
 	argv []string
 	// The current state of the abstract we have parsed
 	abstract *abstract
+	// The values and their sources collected during the last call to Parse()
+	results *resultStore
 	// Sorted list of parsing rules
 	rules ruleList
 	// Our parent parser if this instance is a sub-parser
 	parent *Parser
 	// A collection of stores provided by the user for retrieving values
 	stores []FromStore
+	// The order in which stores are consulted, highest precedence first; set by SetPrecedence()
+	precedence []FromStore
 	// Errors accumulated when adding options
 	errs []error
+	// The value of the --config option added by `LoadConfigFiles`
+	configFile string
+	// The config files loaded during the last call to Parse()
+	configFiles []string
+	// The sub parser of the sub command run during the last call to Parse(), which
+	// provides the results for Source(), Provenance() and Watch()
+	sub *Parser
+	// Guards the results while Watch() applies changes
+	mutex sync.Mutex
+	// Guards the values stored in the user's destinations while Watch() applies changes;
+	// shared with sub parsers
+	values *sync.RWMutex
 	// Each new argument is assigned a sequence depending on when they were added. This
 	// allows us to infer which position the argument should be expected when parsing the command line
 	seqCount int
@@ -95,26 +146,38 @@ func New(config *Config) *Parser {
 	SetDefault(&cfg.ErrorFunc, panicFunc)
 	SetDefault(&cfg.WordWrap, 100)
 	SetDefault(&cfg.Name, path.Base(os.Args[0]))
+	if cfg.Logger == nil {
+		cfg.Logger = StderrLogger()
+	}
 
 	p := &Parser{
 		cfg:      cfg,
 		seqCount: 1,
+		values:   &sync.RWMutex{},
 	}
 
-	// TODO: Consider removing this if we don't copy our subparsers
-	// If rules exist, assume we are a sub parser and
-	// copy all the private fields
-	/*if parent.rules != nil {
-		p.parent = parent
-		p.argv = parent.argv
-		p.abstract = parent.abstract
-		p.rules = parent.rules
-		p.stores = parent.stores
-		p.seqCount = parent.seqCount
-	}*/
 	return p
 }
 
+// Returns a parser for the sub command which shares the argv, stores and config of this parser.
+// The rules of this parser are combined with the rules added to the sub parser when it is parsed.
+func (p *Parser) newSubParser(cmd *rule) *Parser {
+	cfg := p.cfg
+	cfg.Name = strings.TrimPrefix(cmd.Name, subCmdNamePrefix)
+	cfg.Desc = cmd.HelpMsg
+	cfg.Usage = ""
+
+	return &Parser{
+		cfg:        cfg,
+		parent:     p,
+		argv:       p.argv,
+		stores:     p.stores,
+		precedence: p.precedence,
+		seqCount:   p.seqCount,
+		values:     p.values,
+	}
+}
+
 // Returns true if the mode or set of modes is selected
 func (p *Parser) HasMode(mode Mode) bool {
 	return p.cfg.Mode&mode != 0
@@ -146,8 +209,12 @@ func (p *Parser) ParseOrExit() {
 // TODO: Support out of band command bash completions and in-band bash completions
 // Parses command line arguments using os.Args if 'args' is nil.
 func (p *Parser) Parse(ctx context.Context, argv []string) (int, error) {
-	// Clear any previously parsed abstract
+	// Clear any previously parsed abstract and results
 	p.abstract = nil
+	p.mutex.Lock()
+	p.results = nil
+	p.sub = nil
+	p.mutex.Unlock()
 
 	// Report Add() errors
 	if len(p.errs) != 0 {
@@ -178,8 +245,27 @@ func (p *Parser) Parse(ctx context.Context, argv []string) (int, error) {
 				Aliases: []string{"h"},
 			})
 		}
+		// If user requested we load config files, and a config option is not already defined
+		if p.HasMode(LoadConfigFiles) && p.rules.GetRule(configOptionName) == nil {
+			// Only provide the short alias if the user has not claimed it for another option
+			var aliases []string
+			if p.rules.GetRuleByAlias("c") == nil {
+				aliases = []string{"c"}
+			}
+			p.Add(&Option{
+				Help:    "read values from the config file provided",
+				Name:    configOptionName,
+				Env:     p.configEnvVar(),
+				Store:   &p.configFile,
+				Aliases: aliases,
+			})
+		}
 	}
 
+	// Derive environment variables before combining with the parent rules, such that only
+	// the options added to this parser include the name of the sub command
+	p.deriveEnvVars()
+
 	var err error
 	// Combine any rules from any parent parsers and check for duplicate rules
 	if p.rules, err = p.validateRules(nil); err != nil {
@@ -207,13 +293,21 @@ func (p *Parser) Parse(ctx context.Context, argv []string) (int, error) {
 		return ErrorRetCode, err
 	}
 
-	/*if subCmd := p.nextSubCmd(); subCmd != nil {
-		// Run the sub command
-		// TODO: Might not need to make a copy of ourselves, just pass in the current parser
-		return subCmd(ctx, New(p))
-	}*/
+	// Run the sub command which parses the remaining options and arguments with a sub parser
+	if cmd := p.nextSubCmd(); cmd != nil {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		// The sub parser resolves and stores the values of our rules along with its own
+		sub := p.newSubParser(cmd)
+		p.mutex.Lock()
+		p.sub = sub
+		p.mutex.Unlock()
+		return cmd.CommandFunc(ctx, sub)
+	}
 
 	fmt.Printf("abstract: %s\n", p.abstract.String())
+	p.warnSecretArgs()
 	// --help is a special case option, as it short circuits the normal store
 	// and validation of arguments. This allows the user to pass other arguments
 	// along side -h and still get a help message before getting invalid arg errors
@@ -229,33 +323,108 @@ func (p *Parser) Parse(ctx context.Context, argv []string) (int, error) {
 		return ErrorRetCode, err
 	}
 
+	if err := p.runActions(); err != nil {
+		return ErrorRetCode, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	results, err := p.resolve(ctx)
+	if err != nil {
+		return ErrorRetCode, err
+	}
+	p.mutex.Lock()
+	p.results = results
+	p.mutex.Unlock()
+
+	// Apply defaults and validate required values are provided then store values
+	return p.validateAndStore(results)
+}
+
+// Calls the `ActionFunc` of each rule matched in argv in the order the rules appear
+func (p *Parser) runActions() error {
+	nodes := append(nodeList(nil), p.abstract.nodes...)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Pos < nodes[j].Pos
+	})
+
+	for _, node := range nodes {
+		if node.Rule == nil || node.Rule.Action == nil {
+			continue
+		}
+		var value string
+		if node.Value != nil {
+			value = *node.Value
+		}
+		if err := node.Rule.Action(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Retrieves the values for each rule from the stores in order of precedence
+func (p *Parser) resolve(ctx context.Context) (*resultStore, error) {
+	var err error
 	results := newResultStore(p.rules)
 
-	// TODO: Put all the stores in `p.stores` and process them in this for loop.
-	//  This might provide future features like, having a user store take precedence over
-	//  an Env store.
+	// Let the stores know which parser and profile are requesting values
+	scope := Scope{Commands: p.commandPath()}
+	if p.cfg.ProfileOption != "" {
+		if scope.Profile, err = p.peekValue(ctx, p.cfg.ProfileOption); err != nil {
+			return nil, err
+		}
+	}
+	ctx = ContextWithScope(ctx, scope)
 
-	// Retrieve values from any stores provided by the user first
-	for _, store := range p.stores {
+	// Retrieve values from each store, stores later in the list override values from earlier stores
+	for _, store := range p.storeOrder() {
+		if ps, ok := store.(parserStore); ok {
+			if err := ps.bind(ctx, p); err != nil {
+				return nil, err
+			}
+		}
 		if err := results.From(ctx, store); err != nil {
-			return ErrorRetCode, fmt.Errorf("while reading from store '%s': %s", store.Source(), err)
+			return nil, fmt.Errorf("while reading from store '%s': %s", store.Source(), err)
+		}
+		if p.HasMode(ErrOnUnknownKeys) {
+			if err := checkUnknownKeys(ctx, store, p.rules); err != nil {
+				return nil, err
+			}
 		}
 	}
-	fmt.Printf("User store: %+v\n", results.values)
-	if err := results.From(ctx, newEnvStore(p.rules)); err != nil {
-		return ErrorRetCode, err
-	}
-	fmt.Printf("Env store: %+v\n", results.values)
-	if err := results.From(ctx, p.abstract); err != nil {
+	return results, nil
+}
+
+func (p *Parser) validateAndStore(rs *resultStore) (int, error) {
+	if err := p.validate(rs); err != nil {
 		return ErrorRetCode, err
 	}
-	fmt.Printf("Syntax store: %+v\n", results.values)
 
-	// Apply defaults and validate required values are provided then store values
-	return p.validateAndStore(results)
+	for _, rule := range p.rules {
+		value, count, err := rs.Get(context.Background(), rule.Name, rule.Flags)
+		if err != nil {
+			return ErrorRetCode, err
+		}
+		// Nothing to be done; no value to set
+		if count == 0 {
+			continue
+		}
+
+		fmt.Printf("Store(%v,%d)\n", rule.maskString(fmt.Sprint(value), value), count)
+		if err = rule.StoreValue(value, count); err != nil {
+			return ErrorRetCode, fmt.Errorf("invalid value for %s '%s': %s", rule.Type(), rule.Name,
+				rule.maskError(err, value))
+		}
+	}
+	return 0, nil
 }
 
-func (p *Parser) validateAndStore(rs *resultStore) (int, error) {
+// Applies defaults to the results and validates required values are provided
+// and values are acceptable without storing the values
+func (p *Parser) validate(rs *resultStore) error {
 	// TODO: Support option exclusion `--option1 | --option2`
 	// TODO: Support option dependency (option2 cannot be used unless option1 is also defined)
 
@@ -264,7 +433,7 @@ func (p *Parser) validateAndStore(rs *resultStore) (int, error) {
 		args := p.UnProcessedArgs()
 		if len(args) != 0 {
 			// TODO: Review if this is the correct wording for an unknown argument
-			return ErrorRetCode, fmt.Errorf("'%s' was provided but not defined", args[0])
+			return fmt.Errorf("'%s' was provided but not defined", args[0])
 		}
 	}
 
@@ -273,22 +442,29 @@ func (p *Parser) validateAndStore(rs *resultStore) (int, error) {
 		// get the value and how many instances of it where provided via the command line
 		value, count, err := rs.Get(context.Background(), rule.Name, rule.Flags)
 		if err != nil {
-			return ErrorRetCode, err
+			return err
 		}
-		fmt.Printf("[validate]Get(%s,%s) - '%v' %d\n", rule.Name, rule.Kind(), value, count)
+		fmt.Printf("[validate]Get(%s,%s) - '%v' %d\n", rule.Name, rule.Kind(), rule.maskString(fmt.Sprint(value), value), count)
 
 		// if no instances of this rule where found
 		if count == 0 {
 			// Set the default value if provided
 			if rule.Default != nil {
 				if value, count, err = convToKind([]string{*rule.Default}, rule.Flags, 1); err != nil {
-					return ErrorRetCode, err
+					return rule.maskError(err, *rule.Default)
 				}
-				fmt.Printf("default: %+v\n", value)
+				rs.values[rule.Name] = valueSrc{
+					source: defaultSource,
+					value:  value,
+					count:  count,
+					raw:    *rule.Default,
+					secret: rule.HasFlag(Secret),
+				}
+				fmt.Printf("default: %+v\n", rs.values[rule.Name])
 			} else {
 				// and is required
 				if rule.HasFlag(Required) {
-					return ErrorRetCode, errors.New(rule.IsRequiredMessage())
+					return errors.New(rule.IsRequiredMessage())
 				}
 				// Nothing else to be done; no value to set
 				continue
@@ -298,40 +474,83 @@ func (p *Parser) validateAndStore(rs *resultStore) (int, error) {
 		// if the user dis-allows the option to be provided more than once
 		if count > 1 {
 			if rule.HasFlag(isOption) && !rule.HasFlag(CanRepeat) {
-				return ErrorRetCode, fmt.Errorf("unexpected duplicate option '%s' provided", rule.Name)
+				return fmt.Errorf("unexpected duplicate option '%s' provided", rule.Name)
 			}
 		}
 
 		// ensure the value matches one of our choices
 		if len(rule.Choices) != 0 {
-			switch t := value.(type) {
-			case string:
-				if !ContainsString(t, rule.Choices, nil) {
-					return ErrorRetCode, fmt.Errorf("'%s' is an invalid argument for '%s' choose from (%s)",
-						value, rule.Name, strings.Join(rule.Choices, ", "))
-				}
-			case []string:
-				for _, i := range t {
-					if !ContainsString(i, rule.Choices, nil) {
-						return ErrorRetCode, fmt.Errorf("'%s' is an invalid argument for '%s' choose from (%s)",
-							value, rule.Name, strings.Join(rule.Choices, ", "))
-					}
-				}
+			if err := rule.validateChoices(value); err != nil {
+				return err
 			}
 		}
 
-		fmt.Printf("Store(%v,%d)\n", value, count)
-		if err = rule.StoreValue(value, count); err != nil {
-			return ErrorRetCode, fmt.Errorf("invalid value for %s '%s': %s", rule.Type(), rule.Name, err)
+		if len(rule.Validators) != 0 {
+			if err := rule.validateValue(value, count, rs.values[rule.Name].source); err != nil {
+				return err
+			}
 		}
 	}
-	return 0, nil
+	return nil
 }
 
+// Returns the provenance of the value stored for the named rule during the last
+// call to Parse(). Returns false if no source provided a value for the rule.
+func (p *Parser) Source(name string) (Provenance, bool) {
+	results := p.currentResults()
+	if results == nil {
+		return Provenance{}, false
+	}
+	prov, ok := results.Provenance()[name]
+	return prov, ok
+}
+
+// Returns the provenance of every value stored during the last call to Parse()
+// keyed by rule name. This is useful when explaining to an operator why a
+// setting has the value it has.
+func (p *Parser) Provenance() map[string]Provenance {
+	results := p.currentResults()
+	if results == nil {
+		return map[string]Provenance{}
+	}
+	return results.Provenance()
+}
+
+// Add a store to retrieve values from. Values provided by the environment and the command line
+// take precedence over values from stores, stores added later take precedence over stores added
+// earlier. Config files loaded by `LoadConfigFiles` have the lowest precedence. Use SetPrecedence()
+// to change the order.
 func (p *Parser) AddStore(store FromStore) {
 	p.stores = append(p.stores, store)
 }
 
+// Set the order in which stores are consulted, highest precedence first. Use EnvStore(),
+// ConfigStore() and ArgvStore() to place the environment and command line within the order. Stores not included
+// are not consulted. Stores added via AddStore() are ignored once a precedence is set.
+//
+//   // A config file beats the environment, but not the command line
+//   p.SetPrecedence(cli.ArgvStore(), iniStore, cli.EnvStore())
+func (p *Parser) SetPrecedence(stores ...FromStore) {
+	p.precedence = stores
+}
+
+// Returns the stores in the order they should be read, lowest precedence first
+func (p *Parser) storeOrder() []FromStore {
+	if p.precedence == nil {
+		var results []FromStore
+		if p.HasMode(LoadConfigFiles) {
+			results = append(results, ConfigStore())
+		}
+		return append(append(results, p.stores...), EnvStore(), ArgvStore())
+	}
+
+	results := make([]FromStore, 0, len(p.precedence))
+	for i := len(p.precedence) - 1; i >= 0; i-- {
+		results = append(results, p.precedence[i])
+	}
+	return results
+}
+
 func (p *Parser) applyArguments() error {
 	// TODO: Multiple arguments with greedy flag is not allowed
 	// TODO: Arguments with default values that follow greedy arguments are not allowed. (ambiguous)
@@ -380,19 +599,88 @@ func (p *Parser) UnProcessedArgs() []string {
 	return r
 }
 
-func (p *Parser) nextSubCmd() CommandFunc {
+// Logs a warning for each `Secret` value provided on the command line, where
+// the value is visible to other users via the process list
+func (p *Parser) warnSecretArgs() {
+	for _, node := range p.abstract.FindWithFlag(Secret) {
+		// Values read from a file are not visible
+		if node.Value == nil || node.Origin != "" {
+			continue
+		}
+		var alternatives []string
+		if node.Rule.EnvVar != "" {
+			alternatives = append(alternatives, fmt.Sprintf("the '%s' environment variable", node.Rule.EnvVar))
+		}
+		if node.Rule.HasFlag(FromFile) {
+			alternatives = append(alternatives, "'@/path/to/file'")
+		}
+
+		msg := fmt.Sprintf("warning: secret option '--%s' was provided on the command line where it is "+
+			"visible to other users", node.Rule.Name)
+		if len(alternatives) != 0 {
+			msg += "; consider using " + strings.Join(alternatives, " or ") + " instead"
+		}
+		p.cfg.Logger.Println(msg)
+	}
+}
+
+// Returns the names of the sub commands leading to this parser
+func (p *Parser) commandPath() []string {
+	var results []string
+	for parser := p; parser.parent != nil; parser = parser.parent {
+		results = append([]string{parser.cfg.Name}, results...)
+	}
+	return results
+}
+
+// Returns the value of a scalar rule as provided by the command line, the environment or the
+// rule default before the stores are consulted. This allows a rule to influence how values are
+// retrieved from the stores, such as selecting a profile.
+func (p *Parser) peekValue(ctx context.Context, name string) (string, error) {
+	rule := p.rules.GetRule(name)
+	if rule == nil {
+		return "", fmt.Errorf("option '%s' is not defined", name)
+	}
+
+	if !rule.HasFlag(ScalarKind) {
+		return "", fmt.Errorf("option '%s' must store a single value", name)
+	}
+
+	env := EnvStore()
+	if err := env.(parserStore).bind(ctx, p); err != nil {
+		return "", err
+	}
+	for _, store := range []FromStore{p.abstract, env} {
+		value, count, err := store.Get(ctx, name, rule.Flags)
+		if err != nil {
+			return "", err
+		}
+		if s, ok := value.(string); ok && count != 0 {
+			return s, nil
+		}
+	}
+
+	if rule.Default != nil {
+		return *rule.Default, nil
+	}
+	return "", nil
+}
+
+// Returns the first command found in argv which was not already handled by a parent parser
+func (p *Parser) nextSubCmd() *rule {
 	if p.abstract == nil {
 		return nil
 	}
 
 	cmdNodes := p.abstract.FindWithFlag(isCommand)
-	if cmdNodes != nil && len(cmdNodes) != 0 {
-		for _, node := range cmdNodes {
-			if !node.Flags.Has(cmdHandled) {
-				node.Flags.Set(cmdHandled, true)
-				return cmdNodes[0].Rule.CommandFunc
-			}
+	sort.SliceStable(cmdNodes, func(i, j int) bool {
+		return cmdNodes[i].Pos < cmdNodes[j].Pos
+	})
+	for _, node := range cmdNodes {
+		if p.parent != nil && p.parent.rules.GetRule(node.Rule.Name) != nil {
+			continue
 		}
+		return node.Rule
 	}
 	return nil
 }
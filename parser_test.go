@@ -1,6 +1,9 @@
 package cli_test
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"sort"
 	"testing"
@@ -23,8 +26,8 @@ func TestParserNoStore(t *testing.T) {
 		v   cli.Variant
 		err string
 	}{
-		{v: &cli.Option{Name: "foo"}, err: "refusing to add option 'foo'; provide an 'IsSet', 'Store' or 'Count' field"},
-		{v: &cli.Argument{Name: "foo"}, err: "refusing to add argument 'foo'; provide an 'IsSet', 'Store' or 'Count' field"},
+		{v: &cli.Option{Name: "foo"}, err: "refusing to add option 'foo'; provide an 'IsSet', 'Store', 'Count' or 'Action' field"},
+		{v: &cli.Argument{Name: "foo"}, err: "refusing to add argument 'foo'; provide an 'IsSet', 'Store', 'Count' or 'Action' field"},
 		{v: &cli.EnvVar{Name: "foo"}, err: "refusing to add envvar 'foo'; provide an 'IsSet' or 'Store' field"},
 	}
 
@@ -409,6 +412,132 @@ func TestCanRepeat(t *testing.T) {
 	// TODO: Test CanRepeat post and prefix  cp <src> <src> <dst>
 }
 
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Print(args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(args...))
+}
+
+func (l *testLogger) Printf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) Println(args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(args...))
+}
+
+func TestSecretValues(t *testing.T) {
+	var password string
+	var pin int
+	log := &testLogger{}
+
+	newParser := func() *cli.Parser {
+		p := cli.New(&cli.Config{Logger: log})
+		p.Add(
+			&cli.Option{Name: "password", Env: "DB_PASSWORD", Default: "hunter2", Flags: cli.Secret,
+				Store: &password, Help: "the database password"},
+			&cli.Option{Name: "pin", Flags: cli.Secret, Store: &pin},
+		)
+		return p
+	}
+
+	// Defaults are masked in help and generated configs
+	p := newParser()
+	assert.Contains(t, p.GenerateHelp(), "the database password (default=******, env=DB_PASSWORD)")
+	assert.NotContains(t, string(p.GenerateINIConfig()), "hunter2")
+	assert.NotContains(t, string(p.GenerateEnvConfig()), "hunter2")
+
+	_, err := p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, "hunter2", password)
+	assert.Equal(t, "******", p.Provenance()["password"].Raw)
+	assert.Empty(t, log.lines)
+
+	// Values on the command line are masked in provenance and a warning is logged
+	p = newParser()
+	_, err = p.Parse(nil, []string{"--password", "s3cr3t"})
+	require.Nil(t, err)
+	assert.Equal(t, "s3cr3t", password)
+	assert.Equal(t, "******", p.Provenance()["password"].Raw)
+	require.Len(t, log.lines, 1)
+	assert.Equal(t, "warning: secret option '--password' was provided on the command line where it is "+
+		"visible to other users; consider using the 'DB_PASSWORD' environment variable instead", log.lines[0])
+
+	// Values are masked in error messages
+	p = newParser()
+	_, err = p.Parse(nil, []string{"--pin", "not-a-pin"})
+	require.NotNil(t, err)
+	assert.NotContains(t, err.Error(), "not-a-pin")
+	assert.Equal(t, "invalid value for option 'pin': '******' is not an integer", err.Error())
+}
+
+func TestSecretWarningDefaultLogger(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.Nil(t, err)
+	defer func(stderr *os.File) { os.Stderr = stderr }(os.Stderr)
+	os.Stderr = w
+
+	var password string
+	p := cli.New(nil)
+	p.Add(&cli.Option{Name: "password", Flags: cli.Secret, Store: &password})
+
+	_, err = p.Parse(nil, []string{"--password", "s3cr3t"})
+	require.Nil(t, err)
+	w.Close()
+
+	output, err := ioutil.ReadAll(r)
+	require.Nil(t, err)
+	assert.Equal(t, "warning: secret option '--password' was provided on the command line where it is "+
+		"visible to other users\n", string(output))
+}
+
+func TestActions(t *testing.T) {
+	var features []string
+	var level int
+	var name string
+	var calls []string
+
+	p := cli.New(nil)
+	p.Add(
+		// store_const
+		&cli.Option{Name: "quiet", Aliases: []string{"q"}, Action: func(string) error {
+			level = -1
+			return nil
+		}},
+		// append_const
+		&cli.Option{Name: "fast", Action: func(string) error {
+			features = append(features, "fast")
+			return nil
+		}},
+		&cli.Option{Name: "safe", Action: func(string) error {
+			features = append(features, "safe")
+			return nil
+		}},
+		&cli.Option{Name: "name", Store: &name, Action: func(value string) error {
+			calls = append(calls, value)
+			return nil
+		}},
+		&cli.Option{Name: "version", Action: func(string) error {
+			return errors.New("version 1.0.0")
+		}},
+	)
+
+	_, err := p.Parse(nil, []string{"--safe", "--name", "goku", "-q", "--fast", "--safe"})
+	require.Nil(t, err)
+	assert.Equal(t, -1, level)
+	assert.Equal(t, []string{"safe", "fast", "safe"}, features)
+	assert.Equal(t, []string{"goku"}, calls)
+	assert.Equal(t, "goku", name)
+
+	// Actions run before values are validated
+	_, err = p.Parse(nil, []string{"--name", "goku", "--name", "vegeta", "--version"})
+	require.NotNil(t, err)
+	assert.Equal(t, "version 1.0.0", err.Error())
+	assert.Equal(t, []string{"goku", "goku", "vegeta"}, calls)
+}
+
 // TODO: Errors should reference the actual option that caused the issue, not the rule definition name
 //  IE: (unexpected duplicate option 'foo' provided") should be (unexpected duplicate option '-f' provided")
 // TODO: Test interspersed arguments <arg0> <arg1> <cmd> <arg0>
@@ -3,15 +3,24 @@ package cli
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 var regexHasPrefix = regexp.MustCompile(`^(\W+)([\w|-]*)$`)
 
-type CastFunc func(string, interface{}, interface{}) (interface{}, error)
-type ActionFunc func(*rule, string, []string, *int) error
+// Converts the raw value provided by the user into the type of the rule's 'Store'. The value
+// returned must be convertible to the type of the 'Store', or the type of each item when the
+// 'Store' is a slice or map.
+type CastFunc func(value string) (interface{}, error)
+
+// Called each time the rule matches in argv with the value provided, or an empty string if the
+// rule expects no value. Actions run in the order they appear in argv before values are stored
+// and validated. Returning an error aborts Parse() with that error.
+type ActionFunc func(value string) error
 type StoreFunc func(interface{}, int) error
 type CommandFunc func(context.Context, *Parser) (int, error)
 
@@ -38,7 +47,7 @@ const (
 	isEnvVar
 	isExpectingValue
 	isHelpRule // TODO: This should be a generic special case flag
-	cmdHandled
+	isDerivedEnv
 
 	// Public flags
 	Required
@@ -50,8 +59,32 @@ const (
 	ScalarKind
 	SliceKind
 	MapKind
+
+	// The value can be read from a file by providing '@/path/to/file' on the command line or by
+	// setting the '<ENV>_FILE' environment variable. Use '@@' to provide a value beginning with '@'.
+	// Arguments are never read from '@/path/to/file', only from the '<ENV>_FILE' environment variable
+	FromFile
+	// The value is masked wherever it is displayed, such as help messages, generated configs,
+	// provenance and error messages. A warning is logged if the value is provided on the command line
+	Secret
+	// Changes to the value after the initial call to Parse() are rejected by Watch()
+	NoReload
+	// 'int64' and 'uint64' values accept byte sizes with SI or IEC units such as '10MB' or '10MiB'
+	ByteUnits
+	// '[]byte' values are decoded from hex such as 'deadbeef'
+	Hex
+	// '[]byte' values are decoded from base64 using either the standard or URL alphabet
+	Base64
+	// Values are matched to the names and aliases of 'Choices' case insensitively
+	IgnoreCase
+	// Maps also accept keyed environment variables such as 'LABELS_env=prod' in addition to the
+	// comma separated value of 'LABELS'. Without this flag other variables sharing the prefix are ignored
+	EnvKeys
 )
 
+// Displayed in place of the values of rules with the `Secret` flag
+const secretMask = "******"
+
 type rule struct {
 	Sequence    int
 	Name        string
@@ -60,11 +93,38 @@ type rule struct {
 	Default     *string
 	Aliases     []string
 	EnvVar      string
-	Choices     []string
+	Choices     []Choice
 	StoreFuncs  []StoreFunc
 	CommandFunc CommandFunc
 	Usage       string
 	Flags       Flags
+	// The destinations provided via 'Store', used to check values without storing them
+	Dests []interface{}
+	// The destinations provided via 'IsSet' and 'Count' which record that a value was provided
+	Markers []interface{}
+	// The default displayed in help when no 'Default' is provided; IE: the value of a `fmt.Stringer`
+	DisplayDefault string
+	// Applied to the value after it is converted to the type of the 'Store'
+	Validators []Validator
+	// Converts the raw value in place of the registered casts
+	Cast CastFunc
+	// Called each time the rule matches in argv
+	Action ActionFunc
+	// The layouts and clock used to parse `time.Time` values; assigned from the parser's config
+	TimeLayouts []string
+	Now         func() time.Time
+}
+
+// Returns a new rule with the settings of this rule which affect how values are converted,
+// such that values can be converted into new destinations without modifying this rule
+func (r *rule) derive() *rule {
+	return &rule{
+		Flags:       r.Flags,
+		Choices:     r.Choices,
+		Cast:        r.Cast,
+		TimeLayouts: r.TimeLayouts,
+		Now:         r.Now,
+	}
 }
 
 func (r *rule) HasFlag(flag Flags) bool {
@@ -80,6 +140,46 @@ func (r *rule) SetFlag(flag Flags, set bool) {
 	}
 }
 
+// Replaces the values provided within the string with a mask if the rule has the `Secret` flag
+func (r *rule) maskString(s string, values ...interface{}) string {
+	if !r.HasFlag(Secret) {
+		return s
+	}
+	for _, value := range values {
+		for _, v := range valueStrings(value) {
+			if v != "" {
+				s = strings.Replace(s, v, secretMask, -1)
+			}
+		}
+	}
+	return s
+}
+
+// Replaces the values provided within the error message with a mask if the rule has the `Secret` flag
+func (r *rule) maskError(err error, values ...interface{}) error {
+	if err == nil || !r.HasFlag(Secret) {
+		return err
+	}
+	return errors.New(r.maskString(err.Error(), values...))
+}
+
+// Returns the strings which make up a string, slice or map value
+func valueStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case map[string]string:
+		var results []string
+		for key, item := range v {
+			results = append(results, key+"="+item, item)
+		}
+		return results
+	}
+	return nil
+}
+
 func (r *rule) StoreValue(value interface{}, count int) error {
 	for _, f := range r.StoreFuncs {
 		if err := f(value, count); err != nil {
@@ -135,7 +235,9 @@ func (r *rule) generateConfigHelp(wordWrap int) bytes.Buffer {
 	// Append the default value to the end of the help string
 	helpMsg := r.HelpMsg
 	if r.Default != nil {
-		helpMsg += ` (Default:"` + *r.Default + `")`
+		helpMsg += ` (Default:"` + r.maskString(*r.Default, *r.Default) + `")`
+	} else if r.DisplayDefault != "" {
+		helpMsg += ` (Default:"` + r.maskString(r.DisplayDefault, r.DisplayDefault) + `")`
 	}
 
 	// Word wrap the help string
@@ -154,8 +256,11 @@ func (r *rule) GenerateHelp() (string, string) {
 	paren := ""
 
 	if !r.HasFlag(isCommand) {
+		parens = append(parens, r.constraints()...)
 		if r.Default != nil {
-			parens = append(parens, fmt.Sprintf("default=%s", *r.Default))
+			parens = append(parens, fmt.Sprintf("default=%s", r.maskString(*r.Default, *r.Default)))
+		} else if r.DisplayDefault != "" {
+			parens = append(parens, fmt.Sprintf("default=%s", r.maskString(r.DisplayDefault, r.DisplayDefault)))
 		}
 		if r.EnvVar != "" {
 			parens = append(parens, fmt.Sprintf("env=%s", r.EnvVar))
@@ -167,7 +272,6 @@ func (r *rule) GenerateHelp() (string, string) {
 
 	var valueType string
 	// if the option expects a value optionally display this depending on type
-	// TODO: Allow the user to override this when custom type provides Get() interface
 	if r.HasFlag(isOption) && r.HasFlag(isExpectingValue) {
 		valueType = " " + r.TypeUsage()
 	}
@@ -176,6 +280,10 @@ func (r *rule) GenerateHelp() (string, string) {
 		return "  " + r.Name, r.HelpMsg
 	}
 
+	if r.HasFlag(isCommand) {
+		return "  " + strings.TrimPrefix(r.Name, subCmdNamePrefix), r.HelpMsg
+	}
+
 	if r.HasFlag(isEnvVar) {
 		return "  " + r.Name + " " + r.TypeUsage(), r.HelpMsg
 	}
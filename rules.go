@@ -82,7 +82,7 @@ func (r ruleList) ValidateRules() (ruleList, error) {
 			return nil, fmt.Errorf("refusing to parse %s with no name'", rule.Type())
 		}
 
-		if regexHasNonWordPrefix.MatchString(rule.Name) {
+		if regexHasNonWordPrefix.MatchString(rule.Name) && !rule.HasFlag(isCommand) {
 			return nil, fmt.Errorf("'%s' is an invalid name for %s; prefixes on names are not allowed",
 				rule.Name, rule.Type())
 		}
@@ -163,3 +163,69 @@ func (r ruleList) GetRuleByFlag(flag Flags) *rule {
 	}
 	return nil
 }
+
+// Returns true if a key from a store matches a rule name or is a nested key of a rule
+// such as 'labels.env' for the rule 'labels'
+func (r ruleList) MatchesKey(key string) bool {
+	for _, rule := range r {
+		if rule.HasFlag(isCommand) {
+			// Keys in a table named after a sub command are checked by the sub command's parser
+			if strings.HasPrefix(key, strings.TrimPrefix(rule.Name, subCmdNamePrefix)+treePathSeparator) {
+				return true
+			}
+			continue
+		}
+		if key == rule.Name || strings.HasPrefix(key, rule.Name+structNameSeparator) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the name of the rule most similar to 'name'. Returns an empty
+// string if no rule is similar enough to be a likely misspelling
+func (r ruleList) Suggest(name string) string {
+	var result string
+	best := len(name)/3 + 1
+	for _, rule := range r {
+		if rule.HasFlag(isCommand) {
+			continue
+		}
+		if d := levenshtein(name, rule.Name); d <= best {
+			result, best = rule.Name, d
+		}
+	}
+	return result
+}
+
+// Returns the number of single character edits required to change 'a' into 'b'
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
@@ -50,12 +50,8 @@ func scanArgv(p *Parser) (*abstract, error) {
 		return nil, err
 	}
 
-	// TODO: Look for commands before assigning arguments
-	/*for _, rule := range s.rules {
-		if rule.HasFlag(isCommand) {
-
-	}
-	}*/
+	// Look for commands before assigning arguments
+	s.scanCommands()
 
 	// Add nodes for any args which do not have a node
 	for i := range s.argv {
@@ -68,6 +64,24 @@ func scanArgv(p *Parser) (*abstract, error) {
 	return s.abstract, nil
 }
 
+// Adds a node for each positional argument which matches the name of a command
+func (s *scanner) scanCommands() {
+	for i, arg := range s.argv {
+		if s.abstract.AtPos(i) != nil {
+			continue
+		}
+		rule := s.rules.GetRule(subCmdNamePrefix + arg)
+		if rule == nil || !rule.HasFlag(isCommand) {
+			continue
+		}
+		s.abstract.Add(&absNode{
+			Flags: isCommand,
+			Pos:   i,
+			Rule:  rule,
+		})
+	}
+}
+
 func (s *scanner) scanOptions(argPos int) error {
 	if len(s.argv) == argPos {
 		fmt.Println("no more args to scan")
@@ -138,11 +152,9 @@ func (s *scanner) scanOption(argPos, charPos int, allowCombinedOptions bool) err
 				if len(s.argv) <= argPos+1 {
 					return fmt.Errorf("expected option '%s' to have a value", s.argv[argPos])
 				}
-				optionNode := &absNode{
-					Flags: isOption,
-					Pos:   argPos,
-					Value: &s.argv[argPos+1],
-					Rule:  rule,
+				optionNode, err := s.newValueNode(rule, argPos, s.argv[argPos+1])
+				if err != nil {
+					return err
 				}
 				s.abstract.Add(optionNode)
 				s.abstract.Add(&absNode{
@@ -158,25 +170,21 @@ func (s *scanner) scanOption(argPos, charPos int, allowCombinedOptions bool) err
 					return fmt.Errorf("expected option '%s' to have a value after '='", s.argv[argPos])
 				}
 				// the remainder of the option is the value
-				value := option[end+2:]
-				s.abstract.Add(&absNode{
-					Flags: isOption,
-					Pos:   argPos,
-					Value: &value,
-					Rule:  rule,
-				})
+				node, err := s.newValueNode(rule, argPos, option[end+2:])
+				if err != nil {
+					return err
+				}
+				s.abstract.Add(node)
 				return nil
 			}
 
 			if s.hasMode(AllowCombinedValues) {
 				// the remainder of the option is the value
-				value := option[end:]
-				s.abstract.Add(&absNode{
-					Flags: isOption,
-					Pos:   argPos,
-					Value: &value,
-					Rule:  rule,
-				})
+				node, err := s.newValueNode(rule, argPos, option[end:])
+				if err != nil {
+					return err
+				}
+				s.abstract.Add(node)
 				return nil
 			}
 			// If we get here, then we matched part of the option, but it's not our option because
@@ -221,6 +229,34 @@ func (s *scanner) scanOption(argPos, charPos int, allowCombinedOptions bool) err
 	return nil
 }
 
+// Returns a node for the option and the value provided. If the rule has `FromFile` set and the
+// value begins with '@' the value is read from the file named, '@@' escapes a literal '@'
+func (s *scanner) newValueNode(rule *rule, argPos int, value string) (*absNode, error) {
+	node := &absNode{
+		Flags: isOption,
+		Pos:   argPos,
+		Value: &value,
+		Rule:  rule,
+	}
+
+	if !rule.HasFlag(FromFile) || !strings.HasPrefix(value, "@") {
+		return node, nil
+	}
+
+	if strings.HasPrefix(value, "@@") {
+		value = value[1:]
+		return node, nil
+	}
+
+	contents, err := readValueFile(value[1:])
+	if err != nil {
+		return nil, fmt.Errorf("while reading value for option '%s': %s", s.argv[argPos], err)
+	}
+	node.Value = &contents
+	node.Origin = value[1:]
+	return node, nil
+}
+
 // TODO: Move this method to the `Mode` object
 func (s *scanner) hasMode(mode Mode) bool {
 	return s.mode&mode != 0
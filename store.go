@@ -2,13 +2,43 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 )
 
 type valueSrc struct {
 	count  int
 	source string
 	value  interface{}
+	raw    string
+	origin string
+	locked bool
+	secret bool
+}
+
+// Masks the value of secret values when printed
+func (v valueSrc) String() string {
+	value := v.value
+	if v.secret {
+		value = secretMask
+	}
+	return fmt.Sprintf("{source:%s value:%v count:%d}", v.source, value, v.count)
+}
+
+// Describes where the value for a rule came from
+type Provenance struct {
+	// The name of the rule
+	Name string
+	// The source that provided the value; 'cli-args', 'cli-env', 'cli-default' or the
+	// name returned by `FromStore.Source()`
+	Source string
+	// The raw string value as provided by the source; masked if the rule has the `Secret` flag
+	Raw string
+	// Where the value was found within the source; for example 'argv[2]', 'POWER_LEVEL'
+	// or 'config.ini:12'. Empty if the source does not report a location
+	Origin string
 }
 
 type resultStore struct {
@@ -23,6 +53,154 @@ type FromStore interface {
 	Get(context.Context, string, Flags) (interface{}, int, error)
 }
 
+// A FromStore can optionally implement this interface to report the raw
+// string value and the location within the store where the value was found.
+type OriginStore interface {
+	// Returns the raw value and location for the named rule
+	Origin(context.Context, string) (string, string)
+}
+
+// A FromStore can optionally implement this interface to report the keys it contains.
+// When the parser has `ErrOnUnknownKeys` set, keys which match no rule result in an error.
+type KeyLister interface {
+	// Returns the keys visible to the requesting parser
+	Keys(context.Context) []string
+}
+
+// A FromStore can optionally implement this interface to retrieve the values for
+// every rule in a single batch before Get() is called for each rule.
+type BatchStore interface {
+	// Retrieves the values for the named rules
+	Prefetch(context.Context, []string) error
+}
+
+type scopeKey struct{}
+
+// Describes the parser requesting values from a store. Stores which support sections
+// or profiles can use the scope to choose which values to provide.
+type Scope struct {
+	// The names of the sub commands leading to the requesting parser; empty for the root parser
+	Commands []string
+	// The active profile as selected by the option named in `Config.ProfileOption`
+	Profile string
+}
+
+// Returns a copy of the context which carries the scope provided
+func ContextWithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// Returns the scope of the parser requesting values; returns an
+// empty scope if the context has no scope attached
+func ScopeFromContext(ctx context.Context) Scope {
+	if ctx == nil {
+		return Scope{}
+	}
+	scope, _ := ctx.Value(scopeKey{}).(Scope)
+	return scope
+}
+
+// Stores provided by the parser implement this interface so they can be
+// bound to the parser's rules and abstract before values are retrieved
+type parserStore interface {
+	bind(context.Context, *Parser) error
+}
+
+// Wraps a store and prevents the values it provides from being overridden
+// by any other store, regardless of precedence.
+type lockedStore struct {
+	FromStore
+	names []string
+}
+
+// Returns a store whose values for the named rules cannot be overridden by any other
+// store. If no names are provided, every value the store provides is locked. This is
+// useful when an admin config should enforce a setting the command line cannot change.
+//
+//   p.AddStore(cli.Locked(adminStore, "max-connections"))
+func Locked(store FromStore, names ...string) FromStore {
+	return &lockedStore{FromStore: store, names: names}
+}
+
+func (l *lockedStore) isLocked(name string) bool {
+	return len(l.names) == 0 || ContainsString(name, l.names, nil)
+}
+
+func (l *lockedStore) bind(ctx context.Context, p *Parser) error {
+	if ps, ok := l.FromStore.(parserStore); ok {
+		return ps.bind(ctx, p)
+	}
+	return nil
+}
+
+func (l *lockedStore) Changed() bool {
+	if r, ok := l.FromStore.(Reloader); ok {
+		return r.Changed()
+	}
+	return false
+}
+
+func (l *lockedStore) Reload() error {
+	if r, ok := l.FromStore.(Reloader); ok {
+		return r.Reload()
+	}
+	return nil
+}
+
+func (l *lockedStore) Prefetch(ctx context.Context, names []string) error {
+	if b, ok := l.FromStore.(BatchStore); ok {
+		return b.Prefetch(ctx, names)
+	}
+	return nil
+}
+
+func (l *lockedStore) Keys(ctx context.Context) []string {
+	if k, ok := l.FromStore.(KeyLister); ok {
+		return k.Keys(ctx)
+	}
+	return nil
+}
+
+func (l *lockedStore) Origin(ctx context.Context, name string) (string, string) {
+	if o, ok := l.FromStore.(OriginStore); ok {
+		return o.Origin(ctx, name)
+	}
+	return "", ""
+}
+
+// A store that provides values found on the command line
+type argvStore struct {
+	abstract *abstract
+}
+
+// Returns a store that represents the command line arguments for use with SetPrecedence()
+func ArgvStore() FromStore {
+	return &argvStore{}
+}
+
+func (a *argvStore) bind(ctx context.Context, p *Parser) error {
+	a.abstract = p.abstract
+	return nil
+}
+
+func (a *argvStore) Source() string {
+	return cliSource
+}
+
+func (a *argvStore) Get(ctx context.Context, name string, flags Flags) (interface{}, int, error) {
+	if a.abstract == nil {
+		return nil, 0, nil
+	}
+	return a.abstract.Get(ctx, name, flags)
+}
+
+func (a *argvStore) Origin(ctx context.Context, name string) (string, string) {
+	if a.abstract == nil {
+		return "", ""
+	}
+	return a.abstract.Origin(ctx, name)
+}
+
 func newResultStore(rules ruleList) *resultStore {
 	return &resultStore{
 		values: make(map[string]valueSrc),
@@ -31,13 +209,33 @@ func newResultStore(rules ruleList) *resultStore {
 }
 
 func (rs *resultStore) From(ctx context.Context, from FromStore) error {
+	if b, ok := from.(BatchStore); ok {
+		var names []string
+		for _, r := range rs.rules {
+			names = append(names, r.Name)
+		}
+		if err := b.Prefetch(ctx, names); err != nil {
+			return err
+		}
+	}
+
+	locker, _ := from.(*lockedStore)
 	for _, r := range rs.rules {
+		// Values from a locked store cannot be overridden
+		if rs.values[r.Name].locked {
+			continue
+		}
+
 		value, count, err := from.Get(ctx, r.Name, r.Flags)
 		if err != nil {
+			if o, ok := from.(OriginStore); ok {
+				raw, _ := o.Origin(ctx, r.Name)
+				return r.maskError(err, raw)
+			}
 			return err
 		}
 
-		fmt.Printf("[%s] Get(%s, %s) - '%v' '%d'\n", from.Source(), r.Name, r.Kind(), value, count)
+		fmt.Printf("[%s] Get(%s, %s) - '%v' '%d'\n", from.Source(), r.Name, r.Kind(), r.maskString(fmt.Sprint(value), value), count)
 
 		// If store did not provide a value for this rule
 		if count == 0 {
@@ -51,16 +249,29 @@ func (rs *resultStore) From(ctx context.Context, from FromStore) error {
 				r.Name, from.Source(), value)
 		}
 
-		rs.values[r.Name] = valueSrc{
+		src := valueSrc{
 			source: from.Source(),
 			count:  count,
 			value:  value,
+			secret: r.HasFlag(Secret),
 		}
+		if o, ok := from.(OriginStore); ok {
+			src.raw, src.origin = o.Origin(ctx, r.Name)
+		}
+		if locker != nil {
+			src.locked = locker.isLocked(r.Name)
+		}
+		rs.values[r.Name] = src
 	}
 	return nil
 }
 
 func notValidKind(r *rule, value interface{}) bool {
+	// Options which expect no value have no value when provided on the command line
+	if value == nil && !r.HasFlag(isExpectingValue) {
+		return false
+	}
+
 	switch {
 	case r.HasFlag(ScalarKind):
 		if _, ok := value.(string); !ok {
@@ -93,3 +304,68 @@ func (rs *resultStore) Set(name, source string, value interface{}, count int) {
 		count:  count,
 	}
 }
+
+// Returns the provenance of each value found in the store
+func (rs *resultStore) Provenance() map[string]Provenance {
+	results := make(map[string]Provenance, len(rs.values))
+	for name, value := range rs.values {
+		raw := value.raw
+		if value.secret && raw != "" {
+			raw = secretMask
+		}
+		results[name] = Provenance{
+			Name:   name,
+			Source: value.source,
+			Raw:    raw,
+			Origin: value.origin,
+		}
+	}
+	return results
+}
+
+// Returns an error if the store contains a key which matches no rule
+func checkUnknownKeys(ctx context.Context, store FromStore, rules ruleList) error {
+	lister, ok := store.(KeyLister)
+	if !ok {
+		return nil
+	}
+
+	for _, key := range lister.Keys(ctx) {
+		if rules.MatchesKey(key) {
+			continue
+		}
+
+		msg := fmt.Sprintf("unknown key '%s' in store '%s'", key, store.Source())
+		if o, ok := store.(OriginStore); ok {
+			if _, origin := o.Origin(ctx, key); origin != "" {
+				msg += " at " + origin
+			}
+		}
+		if suggest := rules.Suggest(key); suggest != "" {
+			msg += fmt.Sprintf("; did you mean '%s'?", suggest)
+		}
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// Returns the name of the file the reader is reading from if available
+func readerName(r io.Reader) string {
+	if n, ok := r.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return ""
+}
+
+// Returns the 'file:line' or 'line N' location of a value in a file based store
+func fileOrigin(file string, lines []int) string {
+	var results []string
+	for _, line := range lines {
+		if file == "" {
+			results = append(results, fmt.Sprintf("line %d", line))
+			continue
+		}
+		results = append(results, fmt.Sprintf("%s:%d", file, line))
+	}
+	return strings.Join(results, ", ")
+}
@@ -0,0 +1,119 @@
+package cli_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/harbor-pkgs/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	var foo, bar, bang, cat string
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "foo", Store: &foo},
+		&cli.Option{Name: "bar", Store: &bar, Env: "BAR"},
+		&cli.Option{Name: "bang", Store: &bang},
+		&cli.Option{Name: "cat", Store: &cat, Default: "meow"},
+	)
+
+	kv, err := cli.NewIniStore(bytes.NewReader([]byte("# comment\nbang=from-ini\n")))
+	require.Nil(t, err)
+	p.AddStore(kv)
+
+	os.Setenv("BAR", "from-env")
+	defer os.Unsetenv("BAR")
+
+	// Given
+	retCode, err := p.Parse(nil, []string{"--foo", "from-argv"})
+
+	// Then
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+
+	prov, ok := p.Source("foo")
+	require.True(t, ok)
+	assert.Equal(t, cli.Provenance{Name: "foo", Source: "cli-args", Raw: "from-argv", Origin: "argv[0]"}, prov)
+
+	prov, ok = p.Source("bar")
+	require.True(t, ok)
+	assert.Equal(t, cli.Provenance{Name: "bar", Source: "cli-env", Raw: "from-env", Origin: "BAR"}, prov)
+
+	prov, ok = p.Source("bang")
+	require.True(t, ok)
+	assert.Equal(t, cli.Provenance{Name: "bang", Source: "key-value-store", Raw: "from-ini", Origin: "line 2"}, prov)
+
+	prov, ok = p.Source("cat")
+	require.True(t, ok)
+	assert.Equal(t, cli.Provenance{Name: "cat", Source: "cli-default", Raw: "meow"}, prov)
+
+	_, ok = p.Source("help")
+	assert.False(t, ok)
+	assert.Len(t, p.Provenance(), 4)
+}
+
+func TestStorePrecedence(t *testing.T) {
+	var foo, bar string
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "foo", Store: &foo, Env: "FOO"},
+		&cli.Option{Name: "bar", Store: &bar, Env: "BAR"},
+	)
+
+	kv, err := cli.NewIniStore(bytes.NewReader([]byte("foo=from-ini\nbar=from-ini\n")))
+	require.Nil(t, err)
+	p.AddStore(kv)
+
+	os.Setenv("FOO", "from-env")
+	defer os.Unsetenv("FOO")
+
+	// Given the default precedence
+	retCode, err := p.Parse(nil, []string{"--bar", "from-argv"})
+
+	// Then the environment should beat the store
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "from-env", foo)
+	assert.Equal(t, "from-argv", bar)
+
+	// Given the store takes precedence over the environment
+	p.SetPrecedence(cli.ArgvStore(), kv, cli.EnvStore())
+	retCode, err = p.Parse(nil, []string{"--bar", "from-argv"})
+
+	// Then
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "from-ini", foo)
+	assert.Equal(t, "from-argv", bar)
+}
+
+func TestLockedStore(t *testing.T) {
+	var foo, bar string
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "foo", Store: &foo},
+		&cli.Option{Name: "bar", Store: &bar},
+	)
+
+	kv, err := cli.NewIniStore(bytes.NewReader([]byte("foo=locked\nbar=from-ini\n")))
+	require.Nil(t, err)
+	p.AddStore(cli.Locked(kv, "foo"))
+
+	// Given
+	retCode, err := p.Parse(nil, []string{"--foo", "from-argv", "--bar", "from-argv"})
+
+	// Then only 'foo' should be locked
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "locked", foo)
+	assert.Equal(t, "from-argv", bar)
+
+	prov, _ := p.Source("foo")
+	assert.Equal(t, "key-value-store", prov.Source)
+}
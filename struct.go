@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Separates the names of nested structs from the names of their fields
+const structNameSeparator = "."
+
+var setValueType = reflect.TypeOf((*SetValue)(nil)).Elem()
+
+// Maps the names used in the `flags` struct tag to rule flags
+var structTagFlags = map[string]Flags{
+	"required":   Required,
+	"can-repeat": CanRepeat,
+	"no-split":   NoSplit,
+	"hidden":     Hidden,
+	"from-file":  FromFile,
+	"secret":     Secret,
+	"no-reload":  NoReload,
+	"byte-units": ByteUnits,
+	"hex":        Hex,
+	"base64":     Base64,
+	"env-keys":   EnvKeys,
+}
+
+// Adds an option or argument for each tagged field in the struct provided. The
+// fields are used as the 'Store' for each rule added.
+//
+//   type Config struct {
+//       PowerLevel int      `cli:"power-level,p" env:"POWER_LEVEL" default:"10000" help:"set our power level"`
+//       Verbose    int      `cli:"verbose,v" flags:"count" help:"be verbose"`
+//       Name       string   `arg:"name" flags:"required" help:"the name of the hero"`
+//       Server     struct {
+//           Port int `cli:"port" help:"the port to listen on"`
+//       } `cli:"server"`
+//   }
+//
+// The following tags are supported
+//
+//   cli      The name of the option followed by any aliases, separated by a comma
+//   arg      The name of the argument
+//   env      The environment variable that can provide the value
+//   default  The default value if no value is provided
+//   help     The help message displayed to the user
+//   flags    A comma separated list of 'required', 'can-repeat', 'no-split', 'hidden',
+//            'from-file', 'secret', 'no-reload', 'byte-units', 'hex', 'base64', 'env-keys'. Use
+//            'count' or 'is-set' to store the count or presence of an option in an 'int' or 'bool'
+//            field instead of a value.
+//
+// Fields without a `cli` or `arg` tag are ignored. Struct fields tagged with `cli` have their
+// fields added with the name of the struct field as a prefix (IE: 'server.port'). Embedded
+// structs are added without a prefix.
+func (p *Parser) AddStruct(dest interface{}) {
+	reportErr := func(err error) {
+		// Extract the line number and file name that called 'AddStruct'
+		_, file, line, _ := runtime.Caller(2)
+		// Add the error to the parser, to be reported when `Parse()` is called
+		p.errs = append(p.errs, fmt.Errorf("%s:%d - %s", file, line, err))
+	}
+
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.Elem().Kind() != reflect.Struct {
+		reportErr(fmt.Errorf("cannot add struct of type '%T'; must provide a pointer to a struct", dest))
+		return
+	}
+
+	variants, err := structVariants(d.Elem(), "")
+	if err != nil {
+		reportErr(err)
+		return
+	}
+	p.add(2, variants...)
+}
+
+// Walks the fields of the struct value returning a variant for each tagged field
+func structVariants(s reflect.Value, prefix string) ([]Variant, error) {
+	var results []Variant
+	for i := 0; i < s.NumField(); i++ {
+		field := s.Type().Field(i)
+		value := s.Field(i)
+
+		optTag, isOpt := field.Tag.Lookup("cli")
+		argTag, isArg := field.Tag.Lookup("arg")
+
+		// Walk embedded structs as if their fields belonged to the parent
+		if field.Anonymous && !isOpt && !isArg && isStructField(field.Type) {
+			v, err := structVariants(value, prefix)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, v...)
+			continue
+		}
+
+		if (!isOpt && !isArg) || optTag == "-" || argTag == "-" {
+			continue
+		}
+
+		if isOpt && isArg {
+			return nil, fmt.Errorf("field '%s' cannot have both a `cli` and `arg` tag", field.Name)
+		}
+
+		if field.PkgPath != "" {
+			return nil, fmt.Errorf("field '%s' is tagged but not exported", field.Name)
+		}
+
+		// Nested structs are added with the name of the field as a prefix
+		if isOpt && isStructField(field.Type) {
+			name := strings.Split(optTag, ",")[0]
+			if name == "" {
+				return nil, fmt.Errorf("struct field '%s' must provide a name in the `cli` tag", field.Name)
+			}
+			v, err := structVariants(value, prefix+name+structNameSeparator)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, v...)
+			continue
+		}
+
+		v, err := fieldVariant(field, value, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s': %s", field.Name, err)
+		}
+		results = append(results, v)
+	}
+	return results, nil
+}
+
+// Returns true if the type is a struct which should be walked instead of used as a 'Store'
+func isStructField(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	// Structs with a registered cast or that implement SetValue are stored directly
+	if _, ok := scalars[t]; ok {
+		return false
+	}
+	return !reflect.PtrTo(t).Implements(setValueType)
+}
+
+func fieldVariant(field reflect.StructField, value reflect.Value, prefix string) (Variant, error) {
+	var flags Flags
+	var isCount, isSet bool
+
+	for _, item := range ToSlice(field.Tag.Get("flags"), strings.TrimSpace) {
+		switch item {
+		case "count":
+			isCount = true
+		case "is-set":
+			isSet = true
+		default:
+			flag, ok := structTagFlags[item]
+			if !ok {
+				return nil, fmt.Errorf("unknown flag '%s' in `flags` tag", item)
+			}
+			flags |= flag
+		}
+	}
+
+	ptr := value.Addr().Interface()
+	var store interface{} = ptr
+	var count *int
+	var set *bool
+
+	switch {
+	case isCount:
+		c, ok := ptr.(*int)
+		if !ok {
+			return nil, errors.New("flag 'count' requires a field of type 'int'")
+		}
+		count, store = c, nil
+	case isSet:
+		b, ok := ptr.(*bool)
+		if !ok {
+			return nil, errors.New("flag 'is-set' requires a field of type 'bool'")
+		}
+		set, store = b, nil
+	}
+
+	if argTag, ok := field.Tag.Lookup("arg"); ok {
+		return &Argument{
+			Name:    prefix + argTag,
+			Help:    field.Tag.Get("help"),
+			Env:     field.Tag.Get("env"),
+			Default: field.Tag.Get("default"),
+			Flags:   flags,
+			Store:   store,
+			Count:   count,
+			IsSet:   set,
+		}, nil
+	}
+
+	names := ToSlice(field.Tag.Get("cli"), strings.TrimSpace)
+	if len(names) == 0 || names[0] == "" {
+		return nil, errors.New("`cli` tag must provide a name")
+	}
+
+	return &Option{
+		Name:    prefix + names[0],
+		Aliases: names[1:],
+		Help:    field.Tag.Get("help"),
+		Env:     field.Tag.Get("env"),
+		Default: field.Tag.Get("default"),
+		Flags:   flags,
+		Store:   store,
+		Count:   count,
+		IsSet:   set,
+	}, nil
+}
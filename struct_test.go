@@ -0,0 +1,84 @@
+package cli_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/harbor-pkgs/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ServerConfig struct {
+	Port int    `cli:"port" default:"8080" help:"the port to listen on"`
+	Bind string `cli:"bind" help:"the interface to bind too"`
+}
+
+type Embedded struct {
+	Debug bool `cli:"debug,d" flags:"is-set" help:"turn on debug"`
+}
+
+type StructConfig struct {
+	Embedded
+	PowerLevel int          `cli:"power-level,p" env:"POWER_LEVEL" default:"10000" help:"set our power level"`
+	Weapons    []string     `cli:"weapons,w" help:"list of weapons"`
+	Verbose    int          `cli:"verbose,v" flags:"count" help:"be verbose"`
+	Server     ServerConfig `cli:"server"`
+	Ignored    string
+	Skipped    string `cli:"-"`
+}
+
+func TestAddStruct(t *testing.T) {
+	var conf StructConfig
+
+	p := cli.New(nil)
+	p.AddStruct(&conf)
+
+	os.Setenv("POWER_LEVEL", "9001")
+	defer os.Unsetenv("POWER_LEVEL")
+
+	// Given
+	retCode, err := p.Parse(nil, []string{"-d", "-v", "-v", "--weapons", "vi,emacs",
+		"--server.bind", "localhost"})
+
+	// Then
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, true, conf.Debug)
+	assert.Equal(t, 9001, conf.PowerLevel)
+	assert.Equal(t, []string{"vi", "emacs"}, conf.Weapons)
+	assert.Equal(t, 2, conf.Verbose)
+	assert.Equal(t, 8080, conf.Server.Port)
+	assert.Equal(t, "localhost", conf.Server.Bind)
+	assert.Equal(t, "", conf.Ignored)
+}
+
+func TestAddStructErrors(t *testing.T) {
+	var conf StructConfig
+	var badFlag struct {
+		Foo string `cli:"foo" flags:"not-a-flag"`
+	}
+	var badCount struct {
+		Foo string `cli:"foo" flags:"count"`
+	}
+
+	tests := []struct {
+		dest interface{}
+		err  string
+	}{
+		{dest: conf, err: "cannot add struct of type 'cli_test.StructConfig'; must provide a pointer to a struct"},
+		{dest: &badFlag, err: "field 'Foo': unknown flag 'not-a-flag' in `flags` tag"},
+		{dest: &badCount, err: "field 'Foo': flag 'count' requires a field of type 'int'"},
+	}
+
+	for _, test := range tests {
+		p := cli.New(nil)
+		p.AddStruct(test.dest)
+		retCode, err := p.Parse(nil, []string{})
+
+		require.NotNil(t, err)
+		assert.Equal(t, cli.ErrorRetCode, retCode)
+		assert.Contains(t, err.Error(), "struct_test.go")
+		assert.Contains(t, err.Error(), test.err)
+	}
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// When included in `Config.TimeLayouts` values are parsed as the number of seconds since the unix epoch
+const UnixLayout = "unix"
+
+// Returns the layouts used when `Config.TimeLayouts` is not provided
+func DefaultTimeLayouts() []string {
+	return []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+		UnixLayout,
+	}
+}
+
+// Matches the 'd' (day) and 'w' (week) units which `time.ParseDuration` does not support
+var durationDaysRegex = regexp.MustCompile(`([0-9]*\.?[0-9]+)([dw])`)
+
+// Parses a duration as `time.ParseDuration` does with the addition of 'd' (24h) and 'w' (7d) units
+//
+//   d, err := cli.ParseDuration("1w2d12h")
+func ParseDuration(value string) (time.Duration, error) {
+	expanded := durationDaysRegex.ReplaceAllStringFunc(value, func(match string) string {
+		number, _ := strconv.ParseFloat(match[:len(match)-1], 64)
+		hours := 24.0
+		if match[len(match)-1] == 'w' {
+			hours *= 7
+		}
+		return strconv.FormatFloat(number*hours, 'f', -1, 64) + "h"
+	})
+	return time.ParseDuration(expanded)
+}
+
+// Parses a time using the first matching layout provided, or `DefaultTimeLayouts()` if no layouts
+// are provided. Also accepts 'now', 'today', 'yesterday', 'tomorrow' and durations prefixed with
+// '-' or '+' such as '-2h' or '+1d' which are relative to 'now'. Values which do not include a
+// time zone are parsed in the location of 'now'.
+//
+//   t, err := cli.ParseTime("yesterday", nil, time.Now())
+func ParseTime(value string, layouts []string, now time.Time) (time.Time, error) {
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts()
+	}
+	value = strings.TrimSpace(value)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch strings.ToLower(value) {
+	case "now":
+		return now, nil
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	if strings.HasPrefix(value, "-") || strings.HasPrefix(value, "+") {
+		if d, err := ParseDuration(value); err == nil {
+			return now.Add(d), nil
+		}
+	}
+
+	for _, layout := range layouts {
+		if layout == UnixLayout {
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+				nanos := int64((seconds - float64(int64(seconds))) * float64(time.Second))
+				return time.Unix(int64(seconds), nanos).In(now.Location()), nil
+			}
+			continue
+		}
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	var expected []string
+	for _, layout := range layouts {
+		expected = append(expected, fmt.Sprintf("'%s'", layout))
+	}
+	return time.Time{}, fmt.Errorf("'%s' is not a valid time; expected %s or a relative "+
+		"time such as '-2h' or 'yesterday'", value, strings.Join(expected, ", "))
+}
+
+func parseTime(value string) (interface{}, error) {
+	return ParseTime(value, nil, time.Now())
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Returns true if 't' is a `time.Time` or a slice or map of `time.Time`
+func isTimeType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Map:
+		return t.Elem() == timeType
+	}
+	return t == timeType
+}
+
+// Creates the StoreFunc for `time.Time` destinations which parses values using the layouts
+// and clock of the parser the rule was added to, rather than the registered defaults
+func newTimeStoreFunc(r *rule, dest interface{}, d reflect.Value) error {
+	parse := func(value string) (interface{}, error) {
+		now := time.Now
+		if r.Now != nil {
+			now = r.Now
+		}
+		return ParseTime(value, r.TimeLayouts, now())
+	}
+
+	switch d.Kind() {
+	case reflect.Slice:
+		r.SetFlag(SliceKind, true)
+		r.StoreFuncs = append(r.StoreFuncs, sliceParser(parse)(dest))
+		r.Usage = "<time>,<time>"
+	case reflect.Map:
+		if d.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("cannot use '%s'; only '%s' currently supported", d.Type().String(), supportedMaps())
+		}
+		r.SetFlag(MapKind, true)
+		r.StoreFuncs = append(r.StoreFuncs, mapParser(parse)(dest))
+		r.Usage = "<string>=<time>"
+	default:
+		r.SetFlag(ScalarKind, true)
+		r.StoreFuncs = append(r.StoreFuncs, scalarParser(parse)(dest))
+		r.Usage = "<time>"
+	}
+	r.Dests = append(r.Dests, dest)
+	return nil
+}
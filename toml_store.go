@@ -0,0 +1,612 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	regexTOMLInteger = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	regexTOMLHex     = regexp.MustCompile(`^0x[0-9A-Fa-f](_?[0-9A-Fa-f])*$`)
+	regexTOMLOctal   = regexp.MustCompile(`^0o[0-7](_?[0-7])*$`)
+	regexTOMLBinary  = regexp.MustCompile(`^0b[01](_?[01])*$`)
+	regexTOMLFloat   = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)((\.[0-9](_?[0-9])*)([eE][+-]?[0-9](_?[0-9])*)?|[eE][+-]?[0-9](_?[0-9])*)$`)
+	regexTOMLSpecial = regexp.MustCompile(`^[+-]?(inf|nan)$`)
+	regexTOMLDate    = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})?)?$`)
+	regexTOMLTime    = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?$`)
+)
+
+// A store which provides values from a TOML document. Supports a practical subset of TOML;
+// tables, dotted keys, basic and literal strings (including multi-line strings), integers,
+// floats, booleans, arrays and inline tables. Dates and times are provided as strings.
+// Arrays of tables are not supported.
+//
+// Keys within tables are mapped to rule names prefixed by the table name, such that the
+// rule 'server.port' will match
+//
+//   [server]
+//   port = 8080
+//
+// Arrays provide values for `SliceKind` rules and tables provide values for `MapKind` rules.
+// Like the '[run]' section of an INI file, a table named after a sub command provides values
+// for that sub command's parser, falling back to the keys outside of the table
+//
+//   debug = true
+//
+//   [run]
+//   image = "alpine"
+type TOMLStore struct {
+	treeStore
+}
+
+func NewTOMLStore(r io.Reader) (FromStore, error) {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utf8.Valid(contents) {
+		return nil, fmt.Errorf("TOML document is not valid UTF-8")
+	}
+
+	parser := &tomlParser{
+		src:       []rune(string(contents)),
+		line:      1,
+		col:       1,
+		file:      readerName(r),
+		root:      make(map[string]interface{}),
+		locations: make(map[string]string),
+		defined:   make(map[string]bool),
+		dotted:    make(map[string]bool),
+	}
+	if err := parser.parse(); err != nil {
+		return nil, err
+	}
+
+	return &TOMLStore{
+		treeStore: treeStore{
+			source:    "toml-store",
+			format:    "TOML",
+			file:      parser.file,
+			root:      parser.root,
+			locations: parser.locations,
+			commands:  true,
+		},
+	}, nil
+}
+
+type tomlParser struct {
+	src  []rune
+	pos  int
+	line int
+	col  int
+	file string
+	root map[string]interface{}
+	// The location of each key as 'file:line:col' keyed by path
+	locations map[string]string
+	// The table currently receiving key value pairs and its path
+	current     map[string]interface{}
+	currentPath []string
+	// Tables that have been defined by a [table] header
+	defined map[string]bool
+	// Tables that have been defined by dotted keys, which cannot be redefined by a [table] header
+	dotted map[string]bool
+}
+
+func (p *tomlParser) parse() error {
+	p.current = p.root
+	for {
+		p.skipBlank(true)
+		if p.eof() {
+			return nil
+		}
+
+		if p.peek() == '[' {
+			if err := p.parseTable(); err != nil {
+				return err
+			}
+		} else {
+			if err := p.parseKeyValue(p.current, p.currentPath); err != nil {
+				return err
+			}
+		}
+
+		if err := p.expectEndOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// Returns true if the runes at the current position match 's'
+func (p *tomlParser) peekString(s string) bool {
+	runes := []rune(s)
+	if p.pos+len(runes) > len(p.src) {
+		return false
+	}
+	return string(p.src[p.pos:p.pos+len(runes)]) == s
+}
+
+func (p *tomlParser) next() rune {
+	r := p.src[p.pos]
+	p.pos++
+	if r == '\n' {
+		p.line++
+		p.col = 1
+	} else {
+		p.col++
+	}
+	return r
+}
+
+// Skip whitespace and comments, including new lines if 'newLines' is true
+func (p *tomlParser) skipBlank(newLines bool) {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+			p.next()
+		case '\n':
+			if !newLines {
+				return
+			}
+			p.next()
+		case '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.next()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) expectEndOfLine() error {
+	p.skipBlank(false)
+	if p.eof() {
+		return nil
+	}
+	if p.peek() != '\n' {
+		return p.errorf("expected end of line but found '%c'", p.peek())
+	}
+	p.next()
+	return nil
+}
+
+func (p *tomlParser) location() string {
+	if p.file != "" {
+		return fmt.Sprintf("%s:%d:%d", p.file, p.line, p.col)
+	}
+	return fmt.Sprintf("line %d, column %d", p.line, p.col)
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("TOML parse error at %s: %s", p.location(), fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) parseTable() error {
+	p.next()
+	if p.peek() == '[' {
+		return p.errorf("arrays of tables are not supported")
+	}
+
+	p.skipBlank(false)
+	loc := p.location()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipBlank(false)
+	if p.peek() != ']' {
+		return p.errorf("expected ']' to close table header")
+	}
+	p.next()
+
+	path := strings.Join(keys, treePathSeparator)
+	if p.defined[path] {
+		return p.errorf("table '%s' is defined more than once", path)
+	}
+	if p.dotted[path] {
+		return p.errorf("table '%s' is already defined by dotted keys", path)
+	}
+	p.defined[path] = true
+
+	table := p.root
+	for i, key := range keys {
+		next, ok := table[key]
+		if !ok {
+			next = make(map[string]interface{})
+			table[key] = next
+			p.locations[strings.Join(keys[:i+1], treePathSeparator)] = loc
+		}
+		if table, ok = next.(map[string]interface{}); !ok {
+			return p.errorf("key '%s' is already defined as a value", strings.Join(keys[:i+1], treePathSeparator))
+		}
+	}
+	p.current = table
+	p.currentPath = keys
+	return nil
+}
+
+// Parses a possibly dotted key, returning each part of the key
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipBlank(false)
+		var key string
+		var err error
+
+		switch p.peek() {
+		case '"':
+			key, err = p.parseBasicString()
+		case '\'':
+			key, err = p.parseLiteralString()
+		default:
+			key = p.parseBareKey()
+			if key == "" {
+				return nil, p.errorf("expected a key but found '%c'", p.peek())
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		p.skipBlank(false)
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.next()
+	}
+}
+
+func (p *tomlParser) parseBareKey() string {
+	var key []rune
+	for !p.eof() {
+		r := p.peek()
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			break
+		}
+		key = append(key, p.next())
+	}
+	return string(key)
+}
+
+// Parse a 'key = value' pair and assign the value to 'table'
+func (p *tomlParser) parseKeyValue(table map[string]interface{}, prefix []string) error {
+	loc := p.location()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+
+	p.skipBlank(false)
+	if p.peek() != '=' {
+		return p.errorf("expected '=' after key '%s'", strings.Join(keys, treePathSeparator))
+	}
+	p.next()
+	p.skipBlank(false)
+
+	path := append(append([]string{}, prefix...), keys...)
+	value, err := p.parseValue(path)
+	if err != nil {
+		return err
+	}
+
+	// Walk the dotted keys, creating tables as needed
+	for i, key := range keys[:len(keys)-1] {
+		next, ok := table[key]
+		if !ok {
+			next = make(map[string]interface{})
+			table[key] = next
+			p.dotted[strings.Join(append(append([]string{}, prefix...), keys[:i+1]...), treePathSeparator)] = true
+		}
+		if table, ok = next.(map[string]interface{}); !ok {
+			return fmt.Errorf("TOML parse error at %s: key '%s' is already defined as a value",
+				loc, strings.Join(keys[:i+1], treePathSeparator))
+		}
+	}
+
+	last := keys[len(keys)-1]
+	if _, ok := table[last]; ok {
+		return fmt.Errorf("TOML parse error at %s: key '%s' is defined more than once",
+			loc, strings.Join(path, treePathSeparator))
+	}
+	table[last] = value
+	p.locations[strings.Join(path, treePathSeparator)] = loc
+	return nil
+}
+
+func (p *tomlParser) parseValue(path []string) (interface{}, error) {
+	switch {
+	case p.eof():
+		return nil, p.errorf("expected a value but found end of file")
+	case p.peekString(`"""`):
+		return p.parseMultiLineBasicString()
+	case p.peekString(`'''`):
+		return p.parseMultiLineLiteralString()
+	case p.peek() == '"':
+		return p.parseBasicString()
+	case p.peek() == '\'':
+		return p.parseLiteralString()
+	case p.peek() == '[':
+		return p.parseArray(path)
+	case p.peek() == '{':
+		return p.parseInlineTable(path)
+	}
+	return p.parseBareValue()
+}
+
+func (p *tomlParser) parseArray(path []string) (interface{}, error) {
+	p.next()
+	results := []interface{}{}
+	for {
+		p.skipBlank(true)
+		if p.peek() == ']' {
+			p.next()
+			return results, nil
+		}
+
+		value, err := p.parseValue(path)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, value)
+
+		p.skipBlank(true)
+		switch p.peek() {
+		case ',':
+			p.next()
+		case ']':
+			p.next()
+			return results, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable(path []string) (interface{}, error) {
+	p.next()
+	results := make(map[string]interface{})
+	p.skipBlank(false)
+	if p.peek() == '}' {
+		p.next()
+		return results, nil
+	}
+
+	for {
+		if err := p.parseKeyValue(results, path); err != nil {
+			return nil, err
+		}
+
+		p.skipBlank(false)
+		switch p.peek() {
+		case ',':
+			p.next()
+		case '}':
+			p.next()
+			return results, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.next()
+	var result []rune
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		r := p.next()
+		switch r {
+		case '"':
+			return string(result), nil
+		case '\\':
+			escaped, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			result = append(result, escaped)
+		default:
+			result = append(result, r)
+		}
+	}
+}
+
+func (p *tomlParser) parseMultiLineBasicString() (string, error) {
+	p.pos += 3
+	p.col += 3
+	// A new line immediately following the opening delimiter is trimmed
+	p.skipNewLine()
+
+	var result []rune
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if p.peekString(`"""`) {
+			p.pos += 3
+			p.col += 3
+			return string(result), nil
+		}
+
+		r := p.next()
+		if r != '\\' {
+			result = append(result, r)
+			continue
+		}
+
+		// A backslash at the end of a line trims all whitespace up to the next non-whitespace character
+		if p.atLineEndingBackslash() {
+			for !p.eof() && strings.ContainsRune(" \t\r\n", p.peek()) {
+				p.next()
+			}
+			continue
+		}
+
+		escaped, err := p.parseEscape()
+		if err != nil {
+			return "", err
+		}
+		result = append(result, escaped)
+	}
+}
+
+// Returns true if only whitespace remains on the current line
+func (p *tomlParser) atLineEndingBackslash() bool {
+	for i := p.pos; i < len(p.src); i++ {
+		switch p.src[i] {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+func (p *tomlParser) skipNewLine() {
+	if p.peekString("\r\n") {
+		p.next()
+	}
+	if p.peek() == '\n' {
+		p.next()
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.next()
+	var result []rune
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated literal string")
+		}
+		r := p.next()
+		if r == '\'' {
+			return string(result), nil
+		}
+		result = append(result, r)
+	}
+}
+
+func (p *tomlParser) parseMultiLineLiteralString() (string, error) {
+	p.pos += 3
+	p.col += 3
+	p.skipNewLine()
+
+	var result []rune
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line literal string")
+		}
+		if p.peekString(`'''`) {
+			p.pos += 3
+			p.col += 3
+			return string(result), nil
+		}
+		result = append(result, p.next())
+	}
+}
+
+func (p *tomlParser) parseEscape() (rune, error) {
+	if p.eof() {
+		return 0, p.errorf("unterminated escape sequence")
+	}
+	r := p.next()
+	switch r {
+	case 'b':
+		return '\b', nil
+	case 't':
+		return '\t', nil
+	case 'n':
+		return '\n', nil
+	case 'f':
+		return '\f', nil
+	case 'r':
+		return '\r', nil
+	case '"':
+		return '"', nil
+	case '\\':
+		return '\\', nil
+	case 'u', 'U':
+		size := 4
+		if r == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return 0, p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(string(p.src[p.pos:p.pos+size]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return 0, p.errorf("invalid unicode escape '\\%c%s'", r, string(p.src[p.pos:p.pos+size]))
+		}
+		p.pos += size
+		p.col += size
+		return rune(code), nil
+	}
+	return 0, p.errorf("invalid escape sequence '\\%c'", r)
+}
+
+// Parse booleans, numbers, dates and times
+func (p *tomlParser) parseBareValue() (interface{}, error) {
+	line, col := p.line, p.col
+	var token []rune
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", p.peek()) {
+		token = append(token, p.next())
+	}
+
+	// Allow a space between the date and time
+	if regexTOMLDate.MatchString(string(token)) && p.peek() == ' ' && p.pos+3 < len(p.src) &&
+		isDigit(p.src[p.pos+1]) && isDigit(p.src[p.pos+2]) && p.src[p.pos+3] == ':' {
+		token = append(token, p.next())
+		for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", p.peek()) {
+			token = append(token, p.next())
+		}
+	}
+
+	value := string(token)
+	switch {
+	case value == "true" || value == "false":
+		return value, nil
+	case regexTOMLInteger.MatchString(value):
+		return strings.Replace(value, "_", "", -1), nil
+	case regexTOMLHex.MatchString(value) || regexTOMLOctal.MatchString(value) || regexTOMLBinary.MatchString(value):
+		i, err := strconv.ParseUint(strings.Replace(value, "_", "", -1), 0, 64)
+		if err != nil {
+			break
+		}
+		return strconv.FormatUint(i, 10), nil
+	case regexTOMLFloat.MatchString(value) || regexTOMLSpecial.MatchString(value):
+		return strings.Replace(value, "_", "", -1), nil
+	case regexTOMLDate.MatchString(value) || regexTOMLTime.MatchString(value):
+		return value, nil
+	}
+
+	p.line, p.col = line, col
+	if value == "" {
+		return nil, p.errorf("expected a value")
+	}
+	return nil, p.errorf("invalid value '%s'", value)
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
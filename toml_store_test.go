@@ -0,0 +1,161 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/harbor-pkgs/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tomlFile = `
+# A comment
+name = "thrall" # trailing comment
+power-level = 9_001
+mask = 0o755
+debug = true
+ratio = 1.5e3
+weapons = [
+	"axe",
+	'hammer', # Literal string
+]
+labels = { env = "prod", team = "horde" }
+motd = """
+Lok'tar \
+	ogar!
+For the "Horde"\u0021"""
+path = '''C:\Users\thrall'''
+started = 1979-05-27 07:32:00
+
+[server]
+port = 8080
+bind.address = "localhost"
+`
+
+func TestTOMLStore(t *testing.T) {
+	var name, motd, path, started, bind string
+	var power, mask, port int
+	var debug bool
+	var ratio float64
+	var weapons []string
+	var labels map[string]string
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "name", Store: &name},
+		&cli.Option{Name: "power-level", Store: &power},
+		&cli.Option{Name: "mask", Store: &mask},
+		&cli.Option{Name: "debug", Store: &debug},
+		&cli.Option{Name: "ratio", Store: &ratio},
+		&cli.Option{Name: "weapons", Store: &weapons},
+		&cli.Option{Name: "labels", Store: &labels},
+		&cli.Option{Name: "motd", Store: &motd},
+		&cli.Option{Name: "path", Store: &path},
+		&cli.Option{Name: "started", Store: &started},
+		&cli.Option{Name: "server.port", Store: &port},
+		&cli.Option{Name: "server.bind.address", Store: &bind},
+	)
+
+	store, err := cli.NewTOMLStore(bytes.NewReader([]byte(tomlFile)))
+	require.Nil(t, err)
+	p.AddStore(store)
+
+	// Given
+	retCode, err := p.Parse(nil, []string{})
+
+	// Then
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "thrall", name)
+	assert.Equal(t, 9001, power)
+	assert.Equal(t, 0755, mask)
+	assert.Equal(t, true, debug)
+	assert.Equal(t, 1500.0, ratio)
+	assert.Equal(t, []string{"axe", "hammer"}, weapons)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "horde"}, labels)
+	assert.Equal(t, "Lok'tar ogar!\nFor the \"Horde\"!", motd)
+	assert.Equal(t, `C:\Users\thrall`, path)
+	assert.Equal(t, "1979-05-27 07:32:00", started)
+	assert.Equal(t, 8080, port)
+	assert.Equal(t, "localhost", bind)
+
+	prov, ok := p.Source("server.port")
+	require.True(t, ok)
+	assert.Equal(t, "line 21, column 1", prov.Origin)
+}
+
+func TestTOMLStoreErrors(t *testing.T) {
+	tests := []struct {
+		doc string
+		err string
+	}{
+		{doc: "name = ", err: "TOML parse error at line 1, column 8: expected a value but found end of file"},
+		{doc: "name = \"thrall", err: "TOML parse error at line 1, column 15: unterminated string"},
+		{doc: "a = 1\na = 2", err: "TOML parse error at line 2, column 1: key 'a' is defined more than once"},
+		{doc: "a = bogus", err: "TOML parse error at line 1, column 5: invalid value 'bogus'"},
+		{doc: "[a]\n[a]", err: "TOML parse error at line 2, column 4: table 'a' is defined more than once"},
+		{doc: "a.b = 1\n[a]", err: "TOML parse error at line 2, column 4: table 'a' is already defined by dotted keys"},
+		{doc: "[a]\nb.c = 1\n[a.b]", err: "TOML parse error at line 3, column 6: table 'a.b' is already defined by dotted keys"},
+		{doc: "[[a]]", err: "TOML parse error at line 1, column 2: arrays of tables are not supported"},
+		{doc: "a = [1, 2", err: "TOML parse error at line 1, column 10: expected ',' or ']' in array"},
+		{doc: "a = 1 b = 2", err: "TOML parse error at line 1, column 7: expected end of line but found 'b'"},
+	}
+
+	for _, test := range tests {
+		_, err := cli.NewTOMLStore(bytes.NewReader([]byte(test.doc)))
+		require.NotNil(t, err, test.doc)
+		assert.Equal(t, test.err, err.Error())
+	}
+}
+
+func TestTOMLStoreMismatch(t *testing.T) {
+	store, err := cli.NewTOMLStore(bytes.NewReader([]byte(tomlFile)))
+	require.Nil(t, err)
+
+	_, _, err = store.Get(context.TODO(), "weapons", cli.ScalarKind)
+	require.NotNil(t, err)
+	assert.Equal(t, "TOML path 'weapons' at line 8, column 1: expected a scalar value but found an array", err.Error())
+}
+
+func TestTOMLSubCommand(t *testing.T) {
+	var debug bool
+	var user, image string
+
+	p := cli.New(&cli.Config{Mode: cli.ErrOnUnknownKeys})
+	p.Add(
+		&cli.Option{Name: "debug", Store: &debug},
+		&cli.Option{Name: "user", Store: &user},
+		&cli.Command{Name: "run", Help: "run an image", Func: func(ctx context.Context, sub *cli.Parser) (int, error) {
+			sub.Add(&cli.Option{Name: "image", Store: &image})
+			return sub.Parse(ctx, nil)
+		}},
+	)
+
+	store, err := cli.NewTOMLStore(bytes.NewReader([]byte(
+		"debug = true\nuser = \"thrall\"\n\n[run]\nimage = \"alpine\"\nuser = \"jaina\"\n")))
+	require.Nil(t, err)
+	p.AddStore(store)
+
+	// Given the sub command
+	retCode, err := p.Parse(nil, []string{"run"})
+
+	// Then the '[run]' table applies to the sub command
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "alpine", image)
+	assert.Equal(t, "jaina", user)
+	assert.Equal(t, true, debug)
+
+	src, ok := p.Source("image")
+	require.True(t, ok)
+	assert.Equal(t, "line 5, column 1", src.Origin)
+
+	// Without the sub command the '[run]' table is ignored
+	user = ""
+	retCode, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "thrall", user)
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Separates the keys of nested objects when mapping them to rule names
+const treePathSeparator = "."
+
+// A store backed by a tree of nested objects as decoded from structured config files like JSON.
+// Leaf values are strings, arrays are []interface{} and objects are map[string]interface{}
+type treeStore struct {
+	// The name returned by Source()
+	source string
+	// The format of the file, used when reporting errors
+	format string
+	// The name of the file the tree was read from if known
+	file string
+	root map[string]interface{}
+	// Optional location of each value keyed by path; used when reporting errors
+	locations map[string]string
+	// If true, tables named after sub commands provide values for the sub command's parser
+	commands bool
+}
+
+// Returns the path of the table holding values for the sub command of the requesting
+// parser; returns an empty string for the root parser or if sub commands are not supported
+func (t *treeStore) commandPath(ctx context.Context) string {
+	if !t.commands {
+		return ""
+	}
+	return strings.Join(ScopeFromContext(ctx).Commands, treePathSeparator)
+}
+
+// Find the value for a rule name, preferring the table of the requesting parser's sub command
+func (t *treeStore) scopedLookup(ctx context.Context, name string) (interface{}, string, bool) {
+	if cmd := t.commandPath(ctx); cmd != "" {
+		if value, path, ok := t.lookup(cmd + treePathSeparator + name); ok {
+			return value, path, true
+		}
+	}
+	return t.lookup(name)
+}
+
+// Find the value for a rule name; first as a top level key, then as a dotted path into nested objects
+func (t *treeStore) lookup(name string) (interface{}, string, bool) {
+	if v, ok := t.root[name]; ok {
+		return v, name, true
+	}
+
+	var node interface{} = t.root
+	for _, key := range strings.Split(name, treePathSeparator) {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, "", false
+		}
+		if node, ok = obj[key]; !ok {
+			return nil, "", false
+		}
+	}
+	return node, name, true
+}
+
+func (t *treeStore) Source() string {
+	return t.source
+}
+
+func (t *treeStore) Get(ctx context.Context, name string, flags Flags) (interface{}, int, error) {
+	value, path, ok := t.scopedLookup(ctx, name)
+	if !ok || value == nil {
+		return nil, 0, nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return convToKind([]string{v}, flags, 1)
+	case []interface{}:
+		if !flags.Has(SliceKind) {
+			return nil, 0, t.mismatch(path, flags, "an array")
+		}
+		var results []string
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, 0, t.mismatch(fmt.Sprintf("%s[%d]", path, i), ScalarKind, describeTreeValue(item))
+			}
+			results = append(results, s)
+		}
+		return results, 1, nil
+	case map[string]interface{}:
+		if !flags.Has(MapKind) {
+			return nil, 0, t.mismatch(path, flags, "an object")
+		}
+		results := make(map[string]string, len(v))
+		for key, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, 0, t.mismatch(path+treePathSeparator+key, ScalarKind, describeTreeValue(item))
+			}
+			results[key] = s
+		}
+		return results, 1, nil
+	}
+	return nil, 0, fmt.Errorf("%s path '%s': unexpected value type '%T'", t.format, path, value)
+}
+
+// Returns the dotted path of each value in the tree, including the paths relative
+// to the table of the requesting parser's sub command
+func (t *treeStore) Keys(ctx context.Context) []string {
+	var results []string
+	cmd := t.commandPath(ctx)
+	var walk func(string, map[string]interface{})
+	walk = func(prefix string, obj map[string]interface{}) {
+		for key, value := range obj {
+			if child, ok := value.(map[string]interface{}); ok && len(child) != 0 {
+				walk(prefix+key+treePathSeparator, child)
+				continue
+			}
+			results = append(results, prefix+key)
+			if cmd != "" && strings.HasPrefix(prefix, cmd+treePathSeparator) {
+				results = append(results, strings.TrimPrefix(prefix, cmd+treePathSeparator)+key)
+			}
+		}
+	}
+	walk("", t.root)
+	sort.Strings(results)
+	return results
+}
+
+func (t *treeStore) Origin(ctx context.Context, name string) (string, string) {
+	value, path, ok := t.scopedLookup(ctx, name)
+	if !ok {
+		return "", ""
+	}
+
+	var raw string
+	switch v := value.(type) {
+	case string:
+		raw = v
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			items = append(items, fmt.Sprintf("%v", item))
+		}
+		raw = strings.Join(items, ",")
+	case map[string]interface{}:
+		var items []string
+		for key, item := range v {
+			items = append(items, fmt.Sprintf("%s=%v", key, item))
+		}
+		sort.Strings(items)
+		raw = strings.Join(items, ",")
+	}
+
+	if loc, ok := t.locations[path]; ok {
+		return raw, loc
+	}
+	if t.file != "" {
+		return raw, t.file + "#" + path
+	}
+	return raw, path
+}
+
+// Returns an error describing the mismatch between what the rule expected and what was found
+func (t *treeStore) mismatch(path string, flags Flags, found string) error {
+	var expected string
+	switch {
+	case flags.Has(SliceKind):
+		expected = "an array or scalar"
+	case flags.Has(MapKind):
+		expected = "an object or scalar"
+	default:
+		expected = "a scalar"
+	}
+
+	var loc string
+	if l, ok := t.locations[path]; ok {
+		loc = " at " + l
+	}
+	return fmt.Errorf("%s path '%s'%s: expected %s value but found %s", t.format, path, loc, expected, found)
+}
+
+func describeTreeValue(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return "a scalar"
+}
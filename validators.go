@@ -0,0 +1,379 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Validates the value of a rule after it is converted to the type of the rule's 'Store'. Values
+// of slices and maps are validated individually by validators which expect a single value.
+//
+//   p.Add(&cli.Option{Name: "port", Store: &port, Validators: []cli.Validator{cli.Range(1, 65535)}})
+type Validator interface {
+	// Returns an error describing why the value is invalid
+	Validate(value interface{}) error
+	// Returns a short description of the constraint displayed in help such as '1-65535',
+	// or an empty string if the constraint should not be displayed
+	String() string
+}
+
+// A user supplied validator; the constraint is not displayed in help
+//
+//   cli.ValidatorFunc(func(value interface{}) error {
+//       if value.(int)%2 != 0 {
+//           return errors.New("must be an even number")
+//       }
+//       return nil
+//   })
+type ValidatorFunc func(value interface{}) error
+
+func (f ValidatorFunc) Validate(value interface{}) error {
+	return f(value)
+}
+
+func (f ValidatorFunc) String() string {
+	return ""
+}
+
+type rangeValidator struct {
+	min, max interface{}
+}
+
+// Requires numbers, durations and byte sizes to be between 'min' and 'max' inclusive
+//
+//   cli.Range(1, 65535)
+//   cli.Range(time.Second, time.Minute)
+func Range(min, max interface{}) Validator {
+	return &rangeValidator{min: min, max: max}
+}
+
+// Requires numbers, durations and byte sizes to be greater than or equal to 'min'
+func Min(min interface{}) Validator {
+	return &rangeValidator{min: min}
+}
+
+// Requires numbers, durations and byte sizes to be less than or equal to 'max'
+func Max(max interface{}) Validator {
+	return &rangeValidator{max: max}
+}
+
+func (v *rangeValidator) Validate(value interface{}) error {
+	return eachValue(value, func(item interface{}) error {
+		if _, ok := toNumber(item); !ok {
+			return fmt.Errorf("cannot compare '%T' with a range; expected a number", item)
+		}
+		cmpMin, hasMin := compareNumbers(item, v.min)
+		cmpMax, hasMax := compareNumbers(item, v.max)
+		if (hasMin && cmpMin < 0) || (hasMax && cmpMax > 0) {
+			switch {
+			case hasMin && hasMax:
+				return fmt.Errorf("'%v' must be between %v and %v", item, v.min, v.max)
+			case hasMin:
+				return fmt.Errorf("'%v' must be at least %v", item, v.min)
+			default:
+				return fmt.Errorf("'%v' must be at most %v", item, v.max)
+			}
+		}
+		return nil
+	})
+}
+
+func (v *rangeValidator) String() string {
+	switch {
+	case v.min != nil && v.max != nil:
+		return fmt.Sprintf("%v-%v", v.min, v.max)
+	case v.min != nil:
+		return fmt.Sprintf(">=%v", v.min)
+	default:
+		return fmt.Sprintf("<=%v", v.max)
+	}
+}
+
+type matchValidator struct {
+	regex *regexp.Regexp
+}
+
+// Requires strings to match the regular expression provided; panics if the expression is invalid
+//
+//   cli.Match(`^[a-z][a-z0-9-]*$`)
+func Match(pattern string) Validator {
+	return &matchValidator{regex: regexp.MustCompile(pattern)}
+}
+
+func (v *matchValidator) Validate(value interface{}) error {
+	return eachValue(value, func(item interface{}) error {
+		s := reflect.ValueOf(item)
+		if s.Kind() != reflect.String {
+			return fmt.Errorf("cannot match '%T' with a regular expression; expected a string", item)
+		}
+		if !v.regex.MatchString(s.String()) {
+			return fmt.Errorf("'%s' must match '%s'", s.String(), v.regex.String())
+		}
+		return nil
+	})
+}
+
+func (v *matchValidator) String() string {
+	return fmt.Sprintf("matches '%s'", v.regex.String())
+}
+
+type lenValidator struct {
+	min, max int
+}
+
+// Requires slices and maps to have between 'min' and 'max' items and strings to have between
+// 'min' and 'max' characters. A 'max' of zero places no upper limit on the length.
+//
+//   cli.Len(1, 3)
+func Len(min, max int) Validator {
+	return &lenValidator{min: min, max: max}
+}
+
+func (v *lenValidator) Validate(value interface{}) error {
+	var length int
+	unit := "items"
+	r := reflect.ValueOf(value)
+	switch r.Kind() {
+	case reflect.Slice, reflect.Map:
+		length = r.Len()
+	case reflect.String:
+		length, unit = utf8.RuneCountInString(r.String()), "characters"
+	default:
+		return fmt.Errorf("cannot determine the length of '%T'; expected a slice, map or string", value)
+	}
+
+	if length < v.min || (v.max != 0 && length > v.max) {
+		if v.max == 0 {
+			return fmt.Errorf("must have at least %d %s", v.min, unit)
+		}
+		return fmt.Errorf("must have between %d and %d %s", v.min, v.max, unit)
+	}
+	return nil
+}
+
+func (v *lenValidator) String() string {
+	if v.max == 0 {
+		return fmt.Sprintf("length>=%d", v.min)
+	}
+	return fmt.Sprintf("length %d-%d", v.min, v.max)
+}
+
+type pathValidator struct {
+	desc  string
+	check func(path string) error
+}
+
+// Requires the path to exist
+func PathExists() Validator {
+	return &pathValidator{desc: "must exist", check: func(path string) error {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("'%s' does not exist", path)
+		}
+		return nil
+	}}
+}
+
+// Requires the path to be an existing directory
+func IsDir() Validator {
+	return &pathValidator{desc: "directory", check: func(path string) error {
+		stat, err := os.Stat(path)
+		if err != nil || !stat.IsDir() {
+			return fmt.Errorf("'%s' is not a directory", path)
+		}
+		return nil
+	}}
+}
+
+// Requires the path to be an existing regular file
+func IsFile() Validator {
+	return &pathValidator{desc: "file", check: func(path string) error {
+		stat, err := os.Stat(path)
+		if err != nil || !stat.Mode().IsRegular() {
+			return fmt.Errorf("'%s' is not a file", path)
+		}
+		return nil
+	}}
+}
+
+// Requires the parent directory of the path to exist and be writable, such that
+// the path can be created. Useful for output files which may not exist yet.
+func WritableParent() Validator {
+	return &pathValidator{check: func(path string) error {
+		dir := filepath.Dir(path)
+		stat, err := os.Stat(dir)
+		if err != nil || !stat.IsDir() {
+			return fmt.Errorf("parent directory of '%s' does not exist", path)
+		}
+		f, err := os.CreateTemp(dir, ".cli-writable-*")
+		if err != nil {
+			return fmt.Errorf("parent directory of '%s' is not writable", path)
+		}
+		f.Close()
+		os.Remove(f.Name())
+		return nil
+	}}
+}
+
+func (v *pathValidator) Validate(value interface{}) error {
+	return eachValue(value, func(item interface{}) error {
+		s := reflect.ValueOf(item)
+		if s.Kind() != reflect.String {
+			return fmt.Errorf("cannot check path of '%T'; expected a string", item)
+		}
+		return v.check(s.String())
+	})
+}
+
+func (v *pathValidator) String() string {
+	return v.desc
+}
+
+// Calls 'fn' for each item of a slice or each value of a map, or once for any other value
+func eachValue(value interface{}, fn func(interface{}) error) error {
+	r := reflect.ValueOf(value)
+	switch r.Kind() {
+	case reflect.Slice:
+		// Slice types which represent a single value such as `net.IP` are validated as a whole
+		if _, ok := scalars[r.Type()]; ok || r.Type() == bytesType {
+			return fn(value)
+		}
+		for i := 0; i < r.Len(); i++ {
+			if err := fn(r.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		iter := r.MapRange()
+		for iter.Next() {
+			if err := fn(iter.Value().Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fn(value)
+}
+
+// A numeric value including durations and byte sizes. Integers are held exactly such
+// that values beyond the precision of float64 are compared correctly.
+type number struct {
+	kind reflect.Kind
+	i    int64
+	u    uint64
+	f    float64
+}
+
+// Converts numeric values including durations and byte sizes into a number for comparison
+func toNumber(value interface{}) (number, bool) {
+	if value == nil {
+		return number{}, false
+	}
+	r := reflect.ValueOf(value)
+	switch r.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return number{kind: reflect.Int64, i: r.Int()}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return number{kind: reflect.Uint64, u: r.Uint()}, true
+	case reflect.Float32, reflect.Float64:
+		return number{kind: reflect.Float64, f: r.Float()}, true
+	}
+	return number{}, false
+}
+
+func (n number) float() float64 {
+	switch n.kind {
+	case reflect.Int64:
+		return float64(n.i)
+	case reflect.Uint64:
+		return float64(n.u)
+	}
+	return n.f
+}
+
+// Returns -1, 0 or 1 if 'a' is less than, equal to or greater than 'b'. Integers are compared as
+// integers and only compared as floats when either value is a float. Returns false if either value
+// is not a number.
+func compareNumbers(a, b interface{}) (int, bool) {
+	x, ok := toNumber(a)
+	if !ok {
+		return 0, false
+	}
+	y, ok := toNumber(b)
+	if !ok {
+		return 0, false
+	}
+
+	switch {
+	case x.kind == reflect.Float64 || y.kind == reflect.Float64:
+		return compare(x.float() < y.float(), x.float() > y.float()), true
+	case x.kind == reflect.Int64 && y.kind == reflect.Int64:
+		return compare(x.i < y.i, x.i > y.i), true
+	case x.kind == reflect.Uint64 && y.kind == reflect.Uint64:
+		return compare(x.u < y.u, x.u > y.u), true
+	case x.kind == reflect.Int64:
+		// Signed 'x' compared with unsigned 'y'
+		if x.i < 0 {
+			return -1, true
+		}
+		return compare(uint64(x.i) < y.u, uint64(x.i) > y.u), true
+	}
+	// Unsigned 'x' compared with signed 'y'
+	if y.i < 0 {
+		return 1, true
+	}
+	return compare(x.u < uint64(y.i), x.u > uint64(y.i)), true
+}
+
+func compare(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// Converts the value to the type of the rule's 'Store' and applies each of the rule's validators
+func (r *rule) validateValue(value interface{}, count int, source string) error {
+	typed := value
+	if len(r.Dests) != 0 {
+		var err error
+		if typed, err = r.convertValue(r.Dests[0], value, count); err != nil {
+			return fmt.Errorf("invalid value for %s '%s': %s", r.Type(), r.Name, r.maskError(err, value))
+		}
+		// Validate the value of optional destinations rather than the pointer
+		if isOptional(r.Dests[0]) {
+			typed = reflect.ValueOf(typed).Elem().Interface()
+		}
+	}
+
+	for _, v := range r.Validators {
+		if err := v.Validate(typed); err != nil {
+			return &ValidationError{
+				Name:   r.Name,
+				Type:   r.Type(),
+				Source: source,
+				Value:  r.maskString(fmt.Sprint(typed), typed, value),
+				Err:    r.maskError(err, typed, value),
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the descriptions of the rule's validators which are displayed in help
+func (r *rule) constraints() []string {
+	var results []string
+	for _, v := range r.Validators {
+		if s := v.String(); s != "" {
+			results = append(results, s)
+		}
+	}
+	return results
+}
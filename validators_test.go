@@ -0,0 +1,151 @@
+package cli_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harbor-pkgs/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidators(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, filepath.Join(dir, "config.ini"), "")
+
+	var port int
+	var timeout time.Duration
+	var name, input, output, workDir string
+	var tags []string
+	var even int
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "port", Store: &port, Default: "8080", Env: "TEST_PORT", Help: "the port",
+			Validators: []cli.Validator{cli.Range(1, 65535)}},
+		&cli.Option{Name: "timeout", Store: &timeout, Validators: []cli.Validator{cli.Max(time.Minute)}},
+		&cli.Option{Name: "name", Store: &name, Validators: []cli.Validator{cli.Match(`^[a-z]+$`)}},
+		&cli.Option{Name: "tags", Store: &tags, Validators: []cli.Validator{cli.Len(1, 2), cli.Match(`^\w+$`)}},
+		&cli.Option{Name: "input", Store: &input, Validators: []cli.Validator{cli.IsFile()}},
+		&cli.Option{Name: "output", Store: &output, Validators: []cli.Validator{cli.WritableParent()}},
+		&cli.Option{Name: "dir", Store: &workDir, Validators: []cli.Validator{cli.IsDir()}},
+		&cli.Option{Name: "even", Store: &even, Validators: []cli.Validator{
+			cli.ValidatorFunc(func(value interface{}) error {
+				if value.(int)%2 != 0 {
+					return errors.New("must be an even number")
+				}
+				return nil
+			}),
+		}},
+	)
+
+	help := p.GenerateHelp()
+	assert.Contains(t, help, "--port <int>               the port (1-65535, default=8080, env=TEST_PORT)")
+	assert.Contains(t, help, "--timeout <int64>           (<=1m0s)")
+	assert.Contains(t, help, "--tags <string>,<string>    (length 1-2, matches '^\\w+$')")
+
+	_, err := p.Parse(nil, []string{
+		"--timeout", "30s",
+		"--name", "goku",
+		"--tags", "a,b",
+		"--input", file,
+		"--output", filepath.Join(dir, "out.txt"),
+		"--dir", dir,
+		"--even", "2",
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 8080, port)
+	assert.Equal(t, 30*time.Second, timeout)
+
+	os.Setenv("TEST_PORT", "70000")
+	defer os.Unsetenv("TEST_PORT")
+
+	_, err = p.Parse(nil, []string{})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'port' from 'cli-env': '70000' must be between 1 and 65535", err.Error())
+
+	var verr *cli.ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Equal(t, "port", verr.Name)
+	assert.Equal(t, "cli-env", verr.Source)
+	assert.Equal(t, "70000", verr.Value)
+	os.Unsetenv("TEST_PORT")
+
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{
+			args: []string{"--timeout", "2m"},
+			err:  "invalid value for option 'timeout' from 'cli-args': '2m0s' must be at most 1m0s",
+		},
+		{
+			args: []string{"--name", "Goku"},
+			err:  "invalid value for option 'name' from 'cli-args': 'Goku' must match '^[a-z]+$'",
+		},
+		{
+			args: []string{"--tags", "a,b,c"},
+			err:  "invalid value for option 'tags' from 'cli-args': must have between 1 and 2 items",
+		},
+		{
+			args: []string{"--tags", "a,b-c"},
+			err:  "invalid value for option 'tags' from 'cli-args': 'b-c' must match '^\\w+$'",
+		},
+		{
+			args: []string{"--input", dir},
+			err:  "invalid value for option 'input' from 'cli-args': '" + dir + "' is not a file",
+		},
+		{
+			args: []string{"--output", filepath.Join(dir, "missing", "out.txt")},
+			err: "invalid value for option 'output' from 'cli-args': parent directory of '" +
+				filepath.Join(dir, "missing", "out.txt") + "' does not exist",
+		},
+		{
+			args: []string{"--dir", file},
+			err:  "invalid value for option 'dir' from 'cli-args': '" + file + "' is not a directory",
+		},
+		{
+			args: []string{"--even", "3"},
+			err:  "invalid value for option 'even' from 'cli-args': must be an even number",
+		},
+		{
+			args: []string{"--port", "http"},
+			err:  "invalid value for option 'port': 'http' is not an integer",
+		},
+	} {
+		_, err := p.Parse(nil, test.args)
+		require.NotNil(t, err)
+		assert.Equal(t, test.err, err.Error())
+	}
+}
+
+func TestRangeLargeIntegers(t *testing.T) {
+	var size uint64
+	var offset int64
+
+	p := cli.New(nil)
+	p.Add(
+		&cli.Option{Name: "size", Store: &size, Validators: []cli.Validator{cli.Max(uint64(1 << 53))}},
+		&cli.Option{Name: "offset", Store: &offset, Validators: []cli.Validator{cli.Range(-1, int64(1<<62))}},
+	)
+
+	_, err := p.Parse(nil, []string{"--size", "9007199254740992", "--offset", "4611686018427387904"})
+	require.Nil(t, err)
+
+	// Values beyond the precision of float64 are compared exactly
+	_, err = p.Parse(nil, []string{"--size", "9007199254740993"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'size' from 'cli-args': "+
+		"'9007199254740993' must be at most 9007199254740992", err.Error())
+
+	_, err = p.Parse(nil, []string{"--offset", "4611686018427387905"})
+	require.NotNil(t, err)
+	assert.Equal(t, "invalid value for option 'offset' from 'cli-args': "+
+		"'4611686018427387905' must be between -1 and 4611686018427387904", err.Error())
+
+	_, err = p.Parse(nil, []string{"--offset", "-2"})
+	require.NotNil(t, err)
+}
@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The interval at which Watch() checks for changes if `Config.ReloadInterval` is not set
+const defaultReloadInterval = time.Second
+
+// A FromStore can optionally implement this interface such that Watch() can re-read
+// the store when the underlying source changes.
+type Reloader interface {
+	// Returns true if the underlying source has changed since it was last read
+	Changed() bool
+	// Re-reads the underlying source
+	Reload() error
+}
+
+// Called by Watch() with the names of the rules whose values changed, or with the
+// error which prevented the new values from being applied
+type ChangeFunc func(changed []string, err error)
+
+// A store which reads values from a file and re-reads the file when it changes
+type FileStore struct {
+	file    string
+	open    func(io.Reader) (FromStore, error)
+	mutex   sync.Mutex
+	store   FromStore
+	modTime time.Time
+}
+
+// Returns a store which reads values from the file using the store constructor provided.
+// The store implements `Reloader` such that Watch() re-reads the file when it changes.
+//
+//   store, err := cli.NewFileStore("/etc/app/config.ini", cli.NewIniStore)
+func NewFileStore(file string, open func(io.Reader) (FromStore, error)) (FromStore, error) {
+	f := &FileStore{file: file, open: open}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *FileStore) Changed() bool {
+	stat, err := os.Stat(f.file)
+	if err != nil {
+		return false
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return !stat.ModTime().Equal(f.modTime)
+}
+
+func (f *FileStore) Reload() error {
+	fd, err := os.Open(f.file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	stat, err := fd.Stat()
+	if err != nil {
+		return err
+	}
+
+	store, err := f.open(fd)
+	if err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	f.store = store
+	f.modTime = stat.ModTime()
+	f.mutex.Unlock()
+	return nil
+}
+
+func (f *FileStore) current() FromStore {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.store
+}
+
+func (f *FileStore) Source() string {
+	return f.current().Source()
+}
+
+func (f *FileStore) Get(ctx context.Context, name string, flags Flags) (interface{}, int, error) {
+	return f.current().Get(ctx, name, flags)
+}
+
+func (f *FileStore) Origin(ctx context.Context, name string) (string, string) {
+	if o, ok := f.current().(OriginStore); ok {
+		return o.Origin(ctx, name)
+	}
+	return "", ""
+}
+
+func (f *FileStore) Keys(ctx context.Context) []string {
+	if k, ok := f.current().(KeyLister); ok {
+		return k.Keys(ctx)
+	}
+	return nil
+}
+
+func (f *FileStore) bind(ctx context.Context, p *Parser) error {
+	if ps, ok := f.current().(parserStore); ok {
+		return ps.bind(ctx, p)
+	}
+	return nil
+}
+
+// Watches the stores which implement `Reloader` and the config files loaded by `LoadConfigFiles`
+// for changes, polling every `Config.ReloadInterval`. Stores are also re-read each time a signal
+// is received from `Config.ReloadSignal`; SIGHUP is not handled unless the application provides
+// the channel. When a change is detected the values are retrieved and validated as they are by
+// Parse(); if every value is valid the changed values are stored and 'onChange' is called with
+// the names of the rules which changed. If any value is invalid, or a rule with the `NoReload`
+// flag changed, no values are stored and 'onChange' is called with the error.
+//
+// Every changed value is converted before any are stored, then all are stored while holding the
+// parser's write lock. Applications reading values from other goroutines should hold the read
+// lock via RLock() such that they never observe a partially applied reload. Watch() blocks
+// until the context is cancelled and must be called after a successful call to Parse().
+//
+//   go p.Watch(ctx, func(changed []string, err error) {
+//       if err != nil {
+//           log.Printf("config reload failed: %s", err)
+//           return
+//       }
+//       log.Printf("config reloaded; changed %s", strings.Join(changed, ", "))
+//   })
+func (p *Parser) Watch(ctx context.Context, onChange ChangeFunc) error {
+	// The sub parser of a sub command owns the values stored, including the values of our rules
+	if sub := p.subParser(); sub != nil {
+		return sub.Watch(ctx, onChange)
+	}
+
+	if p.currentResults() == nil {
+		return errors.New("no values to watch; call Parse() before calling Watch()")
+	}
+
+	interval := p.cfg.ReloadInterval
+	if interval == 0 {
+		interval = defaultReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	stores := p.storeOrder()
+	configFiles := p.configModTimes()
+	for {
+		var force bool
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.cfg.ReloadSignal:
+			force = true
+		case <-ticker.C:
+		}
+
+		modTimes := p.configModTimes()
+		if !force && !hasChanged(stores) && reflect.DeepEqual(configFiles, modTimes) {
+			continue
+		}
+		configFiles = modTimes
+
+		changed, err := p.reload(ctx, stores, force)
+		if err != nil || len(changed) != 0 {
+			onChange(changed, err)
+		}
+	}
+}
+
+// Returns true if any of the stores which implement `Reloader` have changed
+func hasChanged(stores []FromStore) bool {
+	for _, store := range stores {
+		if r, ok := store.(Reloader); ok && r.Changed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the modification time of each config file in the search path and the file provided via
+// --config such that Watch() can detect when config files are changed, created or removed
+func (p *Parser) configModTimes() map[string]time.Time {
+	if !p.HasMode(LoadConfigFiles) {
+		return nil
+	}
+
+	results := make(map[string]time.Time)
+	for _, file := range append(p.configSearchPaths(), p.configFiles...) {
+		if stat, err := os.Stat(file); err == nil {
+			results[file] = stat.ModTime()
+		}
+	}
+	return results
+}
+
+// Re-reads the stores and applies the changed values if all values are valid
+func (p *Parser) reload(ctx context.Context, stores []FromStore, force bool) ([]string, error) {
+	for _, store := range stores {
+		if r, ok := store.(Reloader); ok && (force || r.Changed()) {
+			if err := r.Reload(); err != nil {
+				return nil, fmt.Errorf("while reloading store '%s': %s", store.Source(), err)
+			}
+		}
+	}
+
+	results, err := p.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.validate(results); err != nil {
+		return nil, err
+	}
+
+	// Convert every changed value before storing any of them, such that a reload
+	// is either applied completely or not at all
+	type update struct {
+		rule   *rule
+		values []interface{}
+		count  int
+	}
+	var updates []update
+	changed := p.currentResults().diff(results)
+	for _, name := range changed {
+		rule := p.rules.GetRule(name)
+		if rule.HasFlag(NoReload) {
+			return nil, fmt.Errorf("%s '%s' cannot be changed without a restart", rule.Type(), rule.Name)
+		}
+
+		value, count, _ := results.Get(ctx, name, rule.Flags)
+		u := update{rule: rule, count: count}
+		if count != 0 {
+			if u.values, err = rule.ConvertValues(value, count); err != nil {
+				return nil, fmt.Errorf("invalid value for %s '%s': %s", rule.Type(), rule.Name,
+					rule.maskError(err, value))
+			}
+		}
+		updates = append(updates, u)
+	}
+
+	p.values.Lock()
+	for _, u := range updates {
+		if u.count == 0 {
+			u.rule.ResetValue()
+			continue
+		}
+		u.rule.ApplyValues(u.values, u.count)
+	}
+	p.values.Unlock()
+
+	p.mutex.Lock()
+	p.results = results
+	p.mutex.Unlock()
+	return changed, nil
+}
+
+// Locks the stored values for reading; Watch() waits for the lock to be released before
+// storing reloaded values. Values stored by a sub command share the lock of the root parser.
+func (p *Parser) RLock() {
+	p.values.RLock()
+}
+
+// Releases the read lock acquired by RLock()
+func (p *Parser) RUnlock() {
+	p.values.RUnlock()
+}
+
+// Returns the results of the last call to Parse(), or the results of the sub parser if a sub command was run
+func (p *Parser) currentResults() *resultStore {
+	if sub := p.subParser(); sub != nil {
+		return sub.currentResults()
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.results
+}
+
+func (p *Parser) subParser() *Parser {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.sub
+}
+
+// Returns the names of the rules whose values differ between the result stores
+func (rs *resultStore) diff(other *resultStore) []string {
+	var results []string
+	for _, rule := range rs.rules {
+		a, b := rs.values[rule.Name], other.values[rule.Name]
+		if a.count != b.count || !reflect.DeepEqual(a.value, b.value) {
+			results = append(results, rule.Name)
+		}
+	}
+	sort.Strings(results)
+	return results
+}
+
+// Converts the value into new instances of the rule's destinations, returning the new instances
+// or any conversion error without modifying the destinations provided by the user
+func (r *rule) ConvertValues(value interface{}, count int) ([]interface{}, error) {
+	var results []interface{}
+	for _, dest := range r.Dests {
+		v, err := r.convertValue(dest, value, count)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, v)
+	}
+	return results, nil
+}
+
+// Stores the values returned by ConvertValues() in the rule's destinations
+// and records the count in the 'IsSet' and 'Count' destinations
+func (r *rule) ApplyValues(values []interface{}, count int) {
+	for i, dest := range r.Dests {
+		d := reflect.ValueOf(dest).Elem()
+		if values[i] == nil {
+			d.Set(reflect.Zero(d.Type()))
+			continue
+		}
+		d.Set(reflect.ValueOf(values[i]))
+	}
+	for _, marker := range r.Markers {
+		switch ptr := marker.(type) {
+		case *bool:
+			*ptr = count != 0
+		case *int:
+			*ptr = count
+		}
+	}
+}
+
+// Converts the value into a new instance of the type of 'dest' and returns the new instance
+func (r *rule) convertValue(dest interface{}, value interface{}, count int) (interface{}, error) {
+	t := reflect.TypeOf(dest)
+	if t.Kind() != reflect.Ptr {
+		return value, nil
+	}
+	check := r.derive()
+	result := reflect.New(t.Elem())
+	if err := newStoreFunc(check, result.Interface()); err != nil {
+		return nil, err
+	}
+	if err := check.StoreValue(value, count); err != nil {
+		return nil, err
+	}
+	return result.Elem().Interface(), nil
+}
+
+// Sets the rule's destinations including 'IsSet' and 'Count' to their zero value; used when a
+// value is removed from a store
+func (r *rule) ResetValue() {
+	for _, dest := range append(append([]interface{}{}, r.Dests...), r.Markers...) {
+		v := reflect.ValueOf(dest)
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			continue
+		}
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+}
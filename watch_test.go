@@ -0,0 +1,214 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/harbor-pkgs/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type change struct {
+	names []string
+	err   error
+}
+
+// Writes the file with a modification time in the future to ensure the change is detected
+func rewriteFile(t *testing.T, file, contents string, age int) {
+	writeFile(t, file, contents)
+	mtime := time.Now().Add(time.Duration(age) * time.Minute)
+	require.Nil(t, os.Chtimes(file, mtime, mtime))
+}
+
+func waitForChange(t *testing.T, changes chan change) change {
+	select {
+	case c := <-changes:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change notification")
+	}
+	return change{}
+}
+
+func TestWatch(t *testing.T) {
+	file := writeFile(t, filepath.Join(t.TempDir(), "config.ini"), "level=1\nname=goku\nport=80\n")
+
+	store, err := cli.NewFileStore(file, cli.NewIniStore)
+	require.Nil(t, err)
+
+	var level, port int
+	var name, color string
+	var hasColor bool
+	p := cli.New(&cli.Config{ReloadInterval: 10 * time.Millisecond})
+	p.Add(
+		&cli.Option{Name: "level", Store: &level},
+		&cli.Option{Name: "name", Store: &name},
+		&cli.Option{Name: "port", Store: &port, Flags: cli.NoReload},
+		&cli.Option{Name: "color", Store: &color, IsSet: &hasColor},
+	)
+	p.AddStore(store)
+
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+	assert.Equal(t, 1, level)
+	assert.Equal(t, "goku", name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan change, 1)
+	done := make(chan error)
+	go func() {
+		done <- p.Watch(ctx, func(names []string, err error) {
+			changes <- change{names: names, err: err}
+		})
+	}()
+
+	// Changed values are applied and reported
+	rewriteFile(t, file, "level=2\nname=goku\nport=80\ncolor=orange\n", 1)
+	c := waitForChange(t, changes)
+	require.Nil(t, c.err)
+	assert.Equal(t, []string{"color", "level"}, c.names)
+	assert.Equal(t, 2, level)
+	assert.Equal(t, "orange", color)
+	assert.True(t, hasColor)
+
+	src, ok := p.Source("level")
+	require.True(t, ok)
+	assert.Equal(t, "2", src.Raw)
+
+	// Invalid values result in an error and no values are applied
+	rewriteFile(t, file, "level=3\nname=vegeta\nport=80\ncolor=blue\nlevel=over-9000\n", 2)
+	c = waitForChange(t, changes)
+	require.NotNil(t, c.err)
+	assert.Equal(t, "unexpected duplicate option 'level' provided", c.err.Error())
+
+	rewriteFile(t, file, "level=over-9000\nname=vegeta\nport=80\n", 3)
+	c = waitForChange(t, changes)
+	require.NotNil(t, c.err)
+	assert.Equal(t, "invalid value for option 'level': 'over-9000' is not an integer", c.err.Error())
+	assert.Equal(t, 2, level)
+	assert.Equal(t, "goku", name)
+	assert.Equal(t, "orange", color)
+
+	// Rules which cannot be reloaded reject changes
+	rewriteFile(t, file, "level=4\nname=vegeta\nport=8080\n", 4)
+	c = waitForChange(t, changes)
+	require.NotNil(t, c.err)
+	assert.Equal(t, "option 'port' cannot be changed without a restart", c.err.Error())
+	assert.Equal(t, 2, level)
+	assert.Equal(t, 80, port)
+
+	// Removed values are reset
+	rewriteFile(t, file, "level=5\nname=vegeta\nport=80\n", 5)
+	c = waitForChange(t, changes)
+	require.Nil(t, c.err)
+	assert.Equal(t, []string{"color", "level", "name"}, c.names)
+	assert.Equal(t, 5, level)
+	assert.Equal(t, "vegeta", name)
+	assert.Equal(t, "", color)
+	assert.False(t, hasColor)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+func TestWatchReloadSignal(t *testing.T) {
+	file := writeFile(t, filepath.Join(t.TempDir(), "config.ini"), "level=1\n")
+
+	store, err := cli.NewFileStore(file, cli.NewIniStore)
+	require.Nil(t, err)
+
+	var level int
+	reload := make(chan os.Signal, 1)
+	p := cli.New(&cli.Config{ReloadInterval: time.Hour, ReloadSignal: reload})
+	p.Add(&cli.Option{Name: "level", Store: &level})
+	p.AddStore(store)
+
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan change, 1)
+	go p.Watch(ctx, func(names []string, err error) {
+		changes <- change{names: names, err: err}
+	})
+
+	// The store is re-read when a signal is received, without waiting for the interval
+	writeFile(t, file, "level=2\n")
+	reload <- syscall.SIGHUP
+	c := waitForChange(t, changes)
+	require.Nil(t, c.err)
+	assert.Equal(t, []string{"level"}, c.names)
+	assert.Equal(t, 2, level)
+}
+
+func TestWatchLock(t *testing.T) {
+	file := writeFile(t, filepath.Join(t.TempDir(), "config.ini"), "color=red\ncount=1\n")
+
+	store, err := cli.NewFileStore(file, cli.NewIniStore)
+	require.Nil(t, err)
+
+	var color string
+	var count int
+	p := cli.New(&cli.Config{ReloadInterval: 10 * time.Millisecond})
+	p.Add(
+		&cli.Option{Name: "color", Store: &color},
+		&cli.Option{Name: "count", Store: &count},
+	)
+	p.AddStore(store)
+
+	_, err = p.Parse(nil, []string{})
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan change, 1)
+	go p.Watch(ctx, func(names []string, err error) {
+		changes <- change{names: names, err: err}
+	})
+
+	// A valid value is not stored when a value converted after it is invalid
+	rewriteFile(t, file, "color=blue\ncount=many\n", 1)
+	c := waitForChange(t, changes)
+	require.NotNil(t, c.err)
+	assert.Equal(t, "invalid value for option 'count': 'many' is not an integer", c.err.Error())
+	assert.Equal(t, "red", color)
+	assert.Equal(t, 1, count)
+
+	// Values are not stored while the read lock is held
+	p.RLock()
+	rewriteFile(t, file, "color=blue\ncount=2\n", 2)
+	select {
+	case <-changes:
+		t.Fatal("values were reloaded while the read lock was held")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Equal(t, "red", color)
+	assert.Equal(t, 1, count)
+	p.RUnlock()
+
+	c = waitForChange(t, changes)
+	require.Nil(t, c.err)
+	assert.Equal(t, []string{"color", "count"}, c.names)
+
+	p.RLock()
+	assert.Equal(t, "blue", color)
+	assert.Equal(t, 2, count)
+	p.RUnlock()
+}
+
+func TestWatchBeforeParse(t *testing.T) {
+	p := cli.New(nil)
+	err := p.Watch(context.Background(), func([]string, error) {})
+	require.NotNil(t, err)
+	assert.Equal(t, "no values to watch; call Parse() before calling Watch()", err.Error())
+}